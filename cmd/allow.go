@@ -0,0 +1,104 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"path/filepath"
+
+	kindly "github.com/borkod/kindly/pkg"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+)
+
+// allowCmd represents the allow command
+var allowCmd = &cobra.Command{
+	Use:   "allow [dir]",
+	Short: "Allows the env command to add the directories of a project.",
+	Long: `Allows the env command and its hook to add the project-local .kindly
+directories of the project in directory dir to the environment.
+
+The default is the project of the closest kindly.yaml of the current directory.
+The project must be allowed again after its kindly.yaml changes.
+
+Examples:
+	kindly allow
+	kindly allow ~/src/myproject`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := projectArg(args)
+		if err := kindly.AllowProject(allowedProjectsFile(), dir); err != nil {
+			fatal(err)
+		}
+		logger.Info("Allowed project", "dir", dir)
+	},
+}
+
+// denyCmd represents the deny command
+var denyCmd = &cobra.Command{
+	Use:   "deny [dir]",
+	Short: "Revokes the permission of the env command to add the directories of a project.",
+	Long: `Revokes the permission of the env command and its hook to add the
+project-local .kindly directories of the project in directory dir to the environment.
+
+The default is the project of the closest kindly.yaml of the current directory.
+
+Examples:
+	kindly deny
+	kindly deny ~/src/myproject`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := projectArg(args)
+		if err := kindly.DenyProject(allowedProjectsFile(), dir); err != nil {
+			fatal(err)
+		}
+		logger.Info("Denied project", "dir", dir)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(allowCmd)
+	rootCmd.AddCommand(denyCmd)
+}
+
+// projectArg returns the project directory of the closest kindly.yaml of directory args[0],
+// or of the current directory if no directory is given
+func projectArg(args []string) string {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		fatal(err)
+	}
+	if p, err := kindly.FindProjectFile(dir); err == nil {
+		dir = filepath.Dir(p)
+	}
+
+	return dir
+}
+
+// allowedProjectsFile returns the file that lists the projects allowed by the allow command
+func allowedProjectsFile() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		fatal(err)
+	}
+	return filepath.Join(home, ".kindly", kindly.AllowedProjectsFileName)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manages the download cache.",
+	Long: `Manages the download cache.
+
+Downloaded files are kept in the cache directory by their SHA256 value,
+and reused by install and update instead of downloading them again.
+
+Examples:
+	kindly cache list
+	kindly cache prune --older-than 720h
+	kindly cache clean`,
+}
+
+// cacheListCmd represents the cache list command
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists files in the download cache.",
+	Long: `Lists files in the download cache.
+
+Example:
+	kindly cache list`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		c, err := k.CacheList()
+		if err != nil {
+			fatal(err)
+		}
+
+		rows := make([][]string, 0, len(c))
+		for _, e := range c {
+			rows = append(rows, []string{e.Sha256, strconv.FormatInt(e.Size, 10), e.Date, e.URL})
+		}
+
+		printOutput(c, []string{"SHA256", "SIZE", "DATE", "URL"}, rows, func() {
+			for _, e := range c {
+				fmt.Printf("%s\t%d\t%s\t%s\n", e.Sha256, e.Size, e.Date, e.URL)
+			}
+		})
+	},
+}
+
+// cacheCleanCmd represents the cache clean command
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Deletes all files in the download cache.",
+	Long: `Deletes all files in the download cache, including partial downloads.
+
+Example:
+	kindly cache clean`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		if err := k.CacheClean(); err != nil {
+			fatal(err)
+		}
+	},
+}
+
+// cachePruneCmd represents the cache prune command
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Deletes files in the download cache that were not used recently.",
+	Long: `Deletes files in the download cache that were last used longer ago than the --older-than duration.
+
+Example:
+	kindly cache prune --older-than 720h`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		p, err := k.CachePrune(viper.GetDuration("older-than"))
+		if err != nil {
+			fatal(err)
+		}
+
+		rows := make([][]string, 0, len(p))
+		for _, e := range p {
+			rows = append(rows, []string{e.Sha256, strconv.FormatInt(e.Size, 10), e.Date, e.URL})
+		}
+
+		printOutput(p, []string{"SHA256", "SIZE", "DATE", "URL"}, rows, func() {
+			for _, e := range p {
+				fmt.Printf("Deleted %s\t%s\n", e.Sha256, e.URL)
+			}
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cacheCleanCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+
+	cachePruneCmd.Flags().Duration("older-than", 30*24*time.Hour, "Delete files last used longer ago than this duration.")
+	if err := viper.BindPFlag("older-than", cachePruneCmd.Flags().Lookup("older-than")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
@@ -20,13 +20,11 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
+	"strings"
 
 	kindly "github.com/borkod/kindly/pkg"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"gopkg.in/yaml.v2"
 )
 
 // checkCmd represents the check command
@@ -35,64 +33,50 @@ var checkCmd = &cobra.Command{
 	Short: "Check if a package is available.",
 	Long: `Check if a package and version is available for your OS.
 	
-Optionally, outputs the Kindly spec for the package.
+Prints the versions of the package that can be installed.
+Optionally, outputs the Kindly spec for the package with --output yaml or json.
 
 Examples:
 	kindly check gh-cli
-	kindly check gh-cli -o`,
+	kindly check gh-cli -o yaml`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		var k kindly.Kindly
-		k.SetConfig(cfg)
-		k.SetLogger(log.New(os.Stdout, "", log.Ltime))
-		log.SetFlags(log.Ltime)
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
 
-		if cfg.Verbose {
-			log.Println("Checking packages...")
-		}
+		logger.Debug("Checking packages")
 
-		// Iterate over all packages provided as command arguments
-		for _, n := range args {
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-			yc, err := k.Check(ctx, n)
-			if err != nil {
-				log.Println("Package: ", n, string("\u001b[31m"), err, string("\u001b[0m"))
-				continue
-			}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
-			// If no YAML output requested, just print one line. Otherwise print YAML.
-			if !viper.GetBool("output") {
-				log.Println("Package: ", n, string("\u001b[32m"), "OK", string("\u001b[0m"))
-			}
+		r := k.CheckAll(ctx, args)
 
-			// If YAML output requested, print complete spec YAML
-			if viper.GetBool("output") {
-				d, err := yaml.Marshal(&yc)
-				if err != nil {
-					log.Println("ERROR: ", err)
+		rows := make([][]string, 0, len(r))
+		for _, c := range r {
+			rows = append(rows, []string{c.Package, c.Version, c.Status, strings.Join(c.Versions, " "), c.Error})
+		}
+
+		printOutput(r, []string{"PACKAGE", "VERSION", "STATUS", "VERSIONS", "ERROR"}, rows, func() {
+			for _, c := range r {
+				if c.Err != nil {
+					fmt.Println("Package:", c.Package, colored(colorRed, c.Err.Error()))
+					continue
 				}
-				fmt.Println("# Package: ", n)
-				fmt.Printf("---\n%s\n", string(d))
+				fmt.Println("Package:", c.Package, colored(colorGreen, "OK"), "Versions:", strings.Join(c.Versions, " "))
 			}
+		})
+
+		var errs kindly.MultiError
+		for _, c := range r {
+			if c.Err != nil {
+				errs = append(errs, c.Err)
+			}
+		}
+		if len(errs) > 0 {
+			os.Exit(exitCode(errs))
 		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(checkCmd)
-
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// checkCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	checkCmd.Flags().BoolP("output", "o", false, "Output YAML spec file.")
-	if err := viper.BindPFlag("output", checkCmd.Flags().Lookup("output")); err != nil {
-		log.Println(err)
-		os.Exit(1)
-	}
 }
@@ -0,0 +1,113 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Prints a shell script that adds kindly directories to the environment.",
+	Long: `Prints a shell script that adds the kindly bin directory to PATH, the man
+pages directory to MANPATH, and loads the installed completions.
+
+Inside a project with a kindly.yaml file, the project-local .kindly directories
+are added in front of the global directories, once the project is allowed with
+'kindly allow'. A project must be allowed again after its kindly.yaml changes.
+
+Use the --hook flag to print a hook that updates the environment whenever the
+current directory changes.
+
+Examples:
+	eval "$(kindly env --shell bash)"
+	eval "$(kindly env --shell zsh --hook)"
+	kindly env --shell fish --hook | source`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(globalCfg), kindly.WithLogger(logger))
+
+		shell := viper.GetString("shell")
+
+		if viper.GetBool("hook") {
+			exe, err := os.Executable()
+			if err != nil {
+				fatal(err)
+			}
+
+			s, err := kindly.EnvHook(shell, exe)
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Print(s)
+			return
+		}
+
+		// Find the project of the current directory.
+		// With --local, the current directory is the project if no kindly.yaml is found.
+		projectDir := ""
+		if dir, err := os.Getwd(); err == nil {
+			if p, err := kindly.FindProjectFile(dir); err == nil {
+				projectDir = filepath.Dir(p)
+			} else if viper.GetBool("local") {
+				projectDir = dir
+			}
+		}
+
+		// Only add the directories of allowed projects
+		if len(projectDir) > 0 {
+			a, err := kindly.ReadAllowedProjects(allowedProjectsFile())
+			if err != nil {
+				fatal(err)
+			}
+			if !a.Allowed(projectDir) {
+				logger.Warn("Project is not allowed, run 'kindly allow' to add its directories", "dir", projectDir)
+				projectDir = ""
+			}
+		}
+
+		s, err := k.Env(shell, projectDir)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Print(s)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+
+	envCmd.Flags().String("shell", "bash", "Shell of the script: bash, zsh or fish.")
+	if err := viper.BindPFlag("shell", envCmd.Flags().Lookup("shell")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	envCmd.Flags().Bool("hook", false, "Print a hook that updates the environment whenever the current directory changes.")
+	if err := viper.BindPFlag("hook", envCmd.Flags().Lookup("hook")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+
+	kindly "github.com/borkod/kindly/pkg"
+)
+
+// Exit codes of commands
+const (
+	exitError      = 1
+	exitNotFound   = 2
+	exitChecksum   = 3
+	exitNetwork    = 4
+	exitFilesystem = 5
+	exitVerify     = 6
+)
+
+// exitCode returns the exit code for error err.
+// The exit code of errors of many packages is the code they all share, or exitError if they differ.
+func exitCode(err error) int {
+	var m kindly.MultiError
+	if errors.As(err, &m) && len(m) > 0 {
+		code := exitCode(m[0])
+		for _, e := range m[1:] {
+			if exitCode(e) != code {
+				return exitError
+			}
+		}
+		return code
+	}
+
+	var urlErr *url.Error
+	var opErr *net.OpError
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	var sysErr *os.SyscallError
+
+	switch {
+	case errors.Is(err, kindly.ErrPackageNotFound), errors.Is(err, kindly.ErrNotInstalled),
+		errors.Is(err, kindly.ErrVersionUnavailable), errors.Is(err, kindly.ErrArchUnavailable):
+		return exitNotFound
+	case errors.Is(err, kindly.ErrChecksumMismatch):
+		return exitChecksum
+	case errors.Is(err, kindly.ErrVerification):
+		return exitVerify
+	case errors.As(err, &urlErr), errors.As(err, &opErr):
+		return exitNetwork
+	case errors.As(err, &pathErr), errors.As(err, &linkErr), errors.As(err, &sysErr):
+		return exitFilesystem
+	}
+
+	return exitError
+}
+
+// fatal logs error err and exits with its exit code
+func fatal(err error) {
+	logger.Error(err.Error())
+	os.Exit(exitCode(err))
+}
+
+// exitResults exits with the exit code of the errors of the failed packages of results r, if any
+func exitResults(r []kindly.PackageResult) {
+	if err := kindly.ResultsError(r); err != nil {
+		os.Exit(exitCode(err))
+	}
+}
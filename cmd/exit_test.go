@@ -0,0 +1,67 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	kindly "github.com/borkod/kindly/pkg"
+)
+
+func TestExitCode(t *testing.T) {
+	// A request to a closed server fails with a network error
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	_, netErr := http.Get(srv.URL)
+	if netErr == nil {
+		t.Fatal("Get() of closed server error = nil")
+	}
+
+	_, pathErr := os.Open("/nonexistent/kindly")
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"error", errors.New("Invalid package version: foo@bar"), exitError},
+		{"package not found", fmt.Errorf("%w: foo", kindly.ErrPackageNotFound), exitNotFound},
+		{"not installed", fmt.Errorf("%w: foo@v1.0.0", kindly.ErrNotInstalled), exitNotFound},
+		{"version unavailable", &kindly.VersionError{Package: "foo", Version: "v9.0.0"}, exitNotFound},
+		{"architecture unavailable", &kindly.ArchError{Package: "foo", OSArch: "plan9_386"}, exitNotFound},
+		{"checksum mismatch", &kindly.ChecksumError{URL: "https://example.com/foo", Algorithm: "sha256"}, exitChecksum},
+		{"verification failed", fmt.Errorf("%w: Invalid index signature", kindly.ErrVerification), exitVerify},
+		{"network", netErr, exitNetwork},
+		{"wrapped network", fmt.Errorf("download interrupted: %w", netErr), exitNetwork},
+		{"filesystem", pathErr, exitFilesystem},
+		{"packages with the same code", kindly.MultiError{fmt.Errorf("%w: foo", kindly.ErrPackageNotFound), fmt.Errorf("%w: bar", kindly.ErrNotInstalled)}, exitNotFound},
+		{"packages with different codes", kindly.MultiError{fmt.Errorf("%w: foo", kindly.ErrPackageNotFound), netErr}, exitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// indexCmd represents the index command
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manages signed spec source indexes.",
+	Long: `Manages signed spec source indexes.
+
+A spec source publishes an index.yaml file listing the name, version and SHA256 value of each spec file,
+and an index.yaml.sig file with the ed25519 signature of the index.
+When a source has a public key configured, kindly verifies every spec against the signed index before using it.
+
+Examples:
+	kindly index keygen
+	kindly index sign ./specs --key private.key
+	kindly index verify ./specs/index.yaml --key public.key`,
+}
+
+// indexKeygenCmd represents the index keygen command
+var indexKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generates an ed25519 key pair for signing indexes.",
+	Long: `Generates an ed25519 key pair for signing indexes.
+
+The base64 encoded keys are written to public.key and private.key in the current directory.
+
+Example:
+	kindly index keygen`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			fatal(err)
+		}
+
+		if err := ioutil.WriteFile("public.key", []byte(base64.StdEncoding.EncodeToString(pub)+"\n"), 0644); err != nil {
+			fatal(err)
+		}
+		if err := ioutil.WriteFile("private.key", []byte(base64.StdEncoding.EncodeToString(priv)+"\n"), 0600); err != nil {
+			fatal(err)
+		}
+
+		fmt.Println(base64.StdEncoding.EncodeToString(pub))
+	},
+}
+
+// indexSignCmd represents the index sign command
+var indexSignCmd = &cobra.Command{
+	Use:   "sign [spec directory]",
+	Short: "Writes a signed index for a directory of spec files.",
+	Long: `Writes index.yaml and index.yaml.sig for a local directory of spec files.
+
+Example:
+	kindly index sign ./specs --key private.key`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		key, err := ioutil.ReadFile(viper.GetString("signkey"))
+		if err != nil {
+			fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		idx, err := kindly.BuildIndex(ctx, args[0])
+		if err != nil {
+			fatal(err)
+		}
+
+		d, err := yaml.Marshal(&idx)
+		if err != nil {
+			fatal(err)
+		}
+
+		sig, err := kindly.SignIndex(d, string(key))
+		if err != nil {
+			fatal(err)
+		}
+
+		filename := filepath.Join(args[0], "index.yaml")
+		if err := ioutil.WriteFile(filename, d, 0644); err != nil {
+			fatal(err)
+		}
+		if err := ioutil.WriteFile(filename+".sig", sig, 0644); err != nil {
+			fatal(err)
+		}
+
+		logger.Debug("Signed index", "path", filename)
+	},
+}
+
+// indexVerifyCmd represents the index verify command
+var indexVerifyCmd = &cobra.Command{
+	Use:   "verify [index file]",
+	Short: "Verifies the signature of a local index file.",
+	Long: `Verifies the signature of a local index file against a public key.
+
+The signature is read from the index file name with a .sig suffix.
+If a spec directory is provided, each spec file in the directory is also checked against the index.
+Verification runs fully offline.
+
+Examples:
+	kindly index verify ./specs/index.yaml --key public.key
+	kindly index verify ./specs/index.yaml ./specs --key public.key`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		key, err := ioutil.ReadFile(viper.GetString("verifykey"))
+		if err != nil {
+			fatal(err)
+		}
+
+		index, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			fatal(err)
+		}
+
+		sig, err := ioutil.ReadFile(args[0] + ".sig")
+		if err != nil {
+			fatal(err)
+		}
+
+		idx, err := kindly.VerifyIndex(index, sig, string(key))
+		if err != nil {
+			fatal(err)
+		}
+
+		if len(args) > 1 {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			specs, err := kindly.BuildIndex(ctx, args[1])
+			if err != nil {
+				fatal(err)
+			}
+
+			failed := false
+			for _, s := range specs.Specs {
+				if !idx.Contains(s) {
+					logger.Error("Spec does not match signed index", "spec", s.Name)
+					failed = true
+				}
+			}
+			if failed {
+				os.Exit(exitVerify)
+			}
+		}
+
+		logger.Info("Index verified", "index", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(indexKeygenCmd)
+	indexCmd.AddCommand(indexSignCmd)
+	indexCmd.AddCommand(indexVerifyCmd)
+
+	indexSignCmd.Flags().StringP("key", "k", "private.key", "Base64 encoded ed25519 private key file.")
+	if err := viper.BindPFlag("signkey", indexSignCmd.Flags().Lookup("key")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	indexVerifyCmd.Flags().StringP("key", "k", "public.key", "Base64 encoded ed25519 public key file.")
+	if err := viper.BindPFlag("verifykey", indexVerifyCmd.Flags().Lookup("key")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
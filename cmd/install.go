@@ -19,8 +19,10 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
+	"strings"
 
 	kindly "github.com/borkod/kindly/pkg"
 	"github.com/spf13/cobra"
@@ -41,34 +43,70 @@ You can use @ to specify a semantic version of the package.
 Example:
 	kindly install gh-cli@v1.0.0
 
+You can also use a version constraint. The highest version listed in the
+package spec that satisfies the constraint is installed, and the package
+is updated within the constraint by the update command.
+
+Examples:
+	kindly install gh-cli@^1.4
+	kindly install gh-cli@~2.1.0
+	kindly install gh-cli@">=1.2 <2"
+
 You can provide multiple arguments to install multiple packages.
+Packages are downloaded concurrently; use --jobs to limit how many at a time.
 	
 Example:
-	kindly install gh-cli ghz`,
-	Args: cobra.MinimumNArgs(1),
+	kindly install gh-cli ghz
+	kindly install --jobs 8 gh-cli ghz
+
+Without arguments, installs the tools listed in the kindly.yaml file of the
+current directory or its closest parent directory. Use the --local flag to
+install them into the .kindly directory of the project.
+
+Examples:
+	kindly install
+	kindly install --local`,
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		var k kindly.Kindly
-		k.SetConfig(cfg)
-		k.SetLogger(log.New(os.Stdout, "", log.Ltime))
-		log.SetFlags(log.Ltime)
-
-		// Iterate over all packages provided as command arguments
-		for _, n := range args {
-			if cfg.Verbose {
-				log.Println("Installing package: ", n)
-			}
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
 
-			if err := k.Install(ctx, n, viper.GetBool("file"), viper.GetBool("url")); err != nil {
-				log.Print(string("\u001b[31m"), err, string("\u001b[0m"), "\n")
-				continue
-			}
+		// Without arguments, install the tools listed in the project file
+		if len(args) == 0 {
+			args = projectTools()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		source := kindly.SourceSpecs
+		switch {
+		case viper.GetBool("file") && viper.GetBool("url"):
+			fatal(errors.New("Only one of 'file' or 'url' flags can be set"))
+		case viper.GetBool("file"):
+			source = kindly.SourceFile
+		case viper.GetBool("url"):
+			source = kindly.SourceURL
 		}
 
-		if cfg.Verbose {
-			log.Println("Installing files complete.")
+		args = uniqueArgs(args)
+		if source == kindly.SourceSpecs {
+			if err := checkConflicts(args); err != nil {
+				fatal(err)
+			}
 		}
+		reqs := make([]kindly.InstallRequest, 0, len(args))
+		for _, a := range args {
+			reqs = append(reqs, kindly.InstallRequest{Ref: a, Source: source})
+		}
+
+		// Install packages concurrently, at most --jobs at a time
+		r := k.InstallAll(ctx, reqs, viper.GetInt("installjobs"))
+
+		printResults(r)
+
+		logger.Debug("Installing files complete")
+
+		exitResults(r)
 	},
 }
 
@@ -93,4 +131,61 @@ func init() {
 		log.Println(err)
 		os.Exit(1)
 	}
+	installCmd.Flags().IntP("jobs", "j", 4, "Number of packages to install at a time.")
+	if err := viper.BindPFlag("installjobs", installCmd.Flags().Lookup("jobs")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
+
+// projectTools returns the tools listed in the closest project file
+func projectTools() []string {
+	dir, err := os.Getwd()
+	if err != nil {
+		fatal(err)
+	}
+
+	filename, err := kindly.FindProjectFile(dir)
+	if err != nil {
+		fatal(err)
+	}
+
+	p, err := kindly.ReadProjectFile(filename)
+	if err != nil {
+		fatal(err)
+	}
+
+	logger.Debug("Installing tools of project file", "path", filename)
+
+	return p.Tools
+}
+
+// uniqueArgs returns args without duplicates, so that a package is not installed twice at the same time
+func uniqueArgs(args []string) []string {
+	seen := make(map[string]bool)
+	u := make([]string, 0, len(args))
+	for _, a := range args {
+		if !seen[a] {
+			seen[a] = true
+			u = append(u, a)
+		}
+	}
+	return u
+}
+
+// checkConflicts returns an error if package refs args request the same package more than once,
+// such as foo@v1.0.0 and kindly/foo@^1, as only one of them would end up installed
+func checkConflicts(args []string) error {
+	refs := make(map[string]string)
+	for _, a := range args {
+		n := strings.SplitN(a, "@", 2)[0]
+		if i := strings.Index(n, "/"); i >= 0 {
+			n = n[i+1:]
+		}
+		if r, ok := refs[n]; ok {
+			return errors.New("Package requested more than once: " + r + ", " + a)
+		}
+		refs[n] = a
+	}
+	return nil
 }
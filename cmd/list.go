@@ -37,26 +37,29 @@ var listCmd = &cobra.Command{
 Example:
 	kindly list`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var k kindly.Kindly
-		k.SetConfig(cfg)
-		k.SetLogger(log.New(os.Stdout, "", log.Ltime))
-		log.SetFlags(log.Ltime)
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
 
-		if cfg.Verbose {
-			log.Println("Listing available packages:")
-		}
+		logger.Debug("Listing packages")
 
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
+		// The packages listed before an error are printed before exiting with its exit code
 		s, err := k.ListPackages(ctx, viper.GetBool("installed"))
-		if err != nil {
-			log.Println(err)
 
+		rows := make([][]string, 0, len(s))
+		for _, p := range s {
+			rows = append(rows, []string{p.Name, p.Version, p.Source})
 		}
 
-		for _, n := range s {
-			fmt.Println(n)
+		printOutput(s, []string{"PACKAGE", "VERSION", "SOURCE"}, rows, func() {
+			for _, p := range s {
+				fmt.Println(p.Name + "@" + p.Version)
+			}
+		})
+
+		if err != nil {
+			fatal(err)
 		}
 	},
 }
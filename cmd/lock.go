@@ -0,0 +1,78 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Writes a lockfile of the installed packages.",
+	Long: `Writes a lockfile of the installed packages.
+
+The lockfile lists each installed package with its exact version, asset URL,
+SHA256 value and source. Use the sync command to install the same packages
+on another machine.
+
+Examples:
+	kindly lock
+	kindly lock --lockfile tools.lock`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		lf, err := k.Lock(ctx)
+		if err != nil {
+			fatal(err)
+		}
+
+		if err := kindly.WriteLockFile(viper.GetString("lockfile"), lf); err != nil {
+			fatal(err)
+		}
+
+		rows := make([][]string, 0, len(lf.Packages))
+		for _, e := range lf.Packages {
+			rows = append(rows, []string{e.Name, e.Version, e.Source, e.Sha256, e.URL})
+		}
+
+		printOutput(lf, []string{"PACKAGE", "VERSION", "SOURCE", "SHA256", "URL"}, rows, func() {
+			logger.Debug("Wrote lockfile", "path", viper.GetString("lockfile"))
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+
+	lockCmd.Flags().String("lockfile", kindly.LockFileName, "Lockfile to write.")
+	if err := viper.BindPFlag("lockfile", lockCmd.Flags().Lookup("lockfile")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// outdatedCmd represents the outdated command
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "Lists installed packages that have newer versions.",
+	Long: `Lists installed packages with their installed, wanted and latest versions.
+
+Wanted is the version the update command would install, within the version
+constraint the package was installed with. Latest is the latest version of
+the package spec. Exits with status 1 if any package is outdated, or with the
+exit code of the errors if the specs of any packages cannot be checked.
+
+Examples:
+	kindly outdated
+	kindly outdated -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		o, err := k.Outdated(ctx, viper.GetInt("outdatedjobs"))
+		if err != nil {
+			fatal(err)
+		}
+
+		outdated := false
+		var errs kindly.MultiError
+		for _, p := range o {
+			outdated = outdated || p.Outdated
+			if p.Err != nil {
+				errs = append(errs, p.Err)
+			}
+		}
+
+		rows := make([][]string, 0, len(o))
+		for _, p := range o {
+			note := ""
+			switch {
+			case p.Err != nil:
+				note = p.Err.Error()
+			case p.Held:
+				note = "held"
+			case len(p.Constraint) > 0:
+				note = "constraint " + p.Constraint
+			}
+			if p.Outdated || p.Err != nil {
+				rows = append(rows, []string{p.Name, p.Installed, p.Wanted, p.Latest, note})
+			}
+		}
+
+		printOutput(o, []string{"PACKAGE", "INSTALLED", "WANTED", "LATEST", "NOTE"}, rows, nil)
+
+		if len(errs) > 0 {
+			os.Exit(exitCode(errs))
+		}
+		if outdated {
+			os.Exit(exitError)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(outdatedCmd)
+
+	outdatedCmd.Flags().IntP("jobs", "j", 4, "Number of package specs to fetch at a time.")
+	if err := viper.BindPFlag("outdatedjobs", outdatedCmd.Flags().Lookup("jobs")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// Formats of the --output flag
+const (
+	outputText  = "text"
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// structuredOutput reports whether the --output flag selects a machine readable format
+func structuredOutput() bool {
+	o := viper.GetString("output")
+	return o == outputJSON || o == outputYAML
+}
+
+// ANSI colors of the text output
+const (
+	colorRed    = "\u001b[31m"
+	colorGreen  = "\u001b[32m"
+	colorYellow = "\u001b[33m"
+	colorReset  = "\u001b[0m"
+)
+
+// colored returns s in ANSI color c if stdout is a terminal
+func colored(c string, s string) string {
+	if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return s
+	}
+	return c + s + colorReset
+}
+
+// printOutput prints v in the format selected by the --output flag.
+// The table format prints the header and rows. The text format calls text,
+// or prints the table if text is nil.
+func printOutput(v interface{}, header []string, rows [][]string, text func()) {
+	switch viper.GetString("output") {
+	case outputJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(string(b))
+	case outputYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Print(string(b))
+	case outputTable:
+		printTable(header, rows)
+	default:
+		if text != nil {
+			text()
+			return
+		}
+		printTable(header, rows)
+	}
+}
+
+// printTable prints rows aligned in columns under header
+func printTable(header []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, r := range rows {
+		fmt.Fprintln(w, strings.Join(r, "\t"))
+	}
+	w.Flush()
+}
+
+// printResults prints package results with a summary line in text format
+func printResults(r []kindly.PackageResult) {
+	rows := make([][]string, 0, len(r))
+	for _, p := range r {
+		rows = append(rows, []string{p.Package, p.Version, p.Status, p.Error})
+	}
+
+	printOutput(r, []string{"PACKAGE", "VERSION", "STATUS", "ERROR"}, rows, func() {
+		failed := 0
+		for _, p := range r {
+			if p.Err != nil {
+				failed++
+				fmt.Println("Package:", p.Package, colored(colorRed, p.Status), p.Err)
+				continue
+			}
+			if p.Status == kindly.StatusHeld {
+				fmt.Println("Package:", p.Package, p.Version, colored(colorYellow, "skipped, package is held; run 'kindly unpin "+p.Package+"' to update it"))
+				continue
+			}
+			fmt.Println("Package:", p.Package, p.Version, colored(colorGreen, p.Status))
+		}
+
+		fmt.Printf("%d package(s) processed, %d failed\n", len(r), failed)
+	})
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"context"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/cobra"
+)
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:   "pin [name of package]",
+	Short: "Holds installed package(s) at their current version.",
+	Long: `Holds installed package(s) at their current version.
+Held packages are skipped by the update command until they are unpinned.
+
+Example:
+	kindly pin terraform`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r := make([]kindly.PackageResult, 0, len(args))
+		for _, n := range args {
+			p := kindly.PackageResult{Package: n, Status: kindly.StatusPinned}
+			if err := k.Pin(ctx, n); err != nil {
+				p.Fail(err)
+			}
+			r = append(r, p)
+		}
+
+		printResults(r)
+		exitResults(r)
+	},
+}
+
+// unpinCmd represents the unpin command
+var unpinCmd = &cobra.Command{
+	Use:   "unpin [name of package]",
+	Short: "Releases held package(s) so that they are updated again.",
+	Long: `Releases held package(s) so that they are updated again.
+
+Example:
+	kindly unpin terraform`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r := make([]kindly.PackageResult, 0, len(args))
+		for _, n := range args {
+			p := kindly.PackageResult{Package: n, Status: kindly.StatusUnpinned}
+			if err := k.Unpin(ctx, n); err != nil {
+				p.Fail(err)
+			}
+			r = append(r, p)
+		}
+
+		printResults(r)
+		exitResults(r)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+}
@@ -19,6 +19,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"log"
 	"os"
@@ -42,24 +43,21 @@ Examples:
 	kindly remove gh-cli
 	kindly remove -a`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var k kindly.Kindly
-		k.SetConfig(cfg)
-		k.SetLogger(log.New(os.Stdout, "", log.Ltime))
-		log.SetFlags(log.Ltime)
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
 
 		if !viper.GetBool("removeall") && len(args) == 0 {
-			log.Fatalln("Must provide a package name as an argument.")
+			fatal(errors.New("Must provide a package name as an argument"))
 		}
 
 		if viper.GetBool("removeall") {
 			if len(args) > 0 {
-				log.Println("Remove All flag is set. Ignoring all other arguments.")
+				logger.Warn("All flag is set, ignoring all other arguments")
 			}
 			args = make([]string, 0)
 
 			files, err := ioutil.ReadDir(cfg.ManifestDir)
 			if err != nil {
-				log.Fatalln(err)
+				fatal(err)
 			}
 
 			for _, n := range files {
@@ -68,23 +66,16 @@ Examples:
 				}
 			}
 		}
-		// Iterate over all packages provided as command arguments
-		for _, n := range args {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
-			if cfg.Verbose {
-				log.Println("Removing package: ", n)
-			}
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-			if err := k.Remove(ctx, n); err != nil {
-				log.Print(string("\u001b[31m"), err, string("\u001b[0m"), "\n")
-				continue
-			}
-		}
+		r := k.RemoveAll(ctx, uniqueArgs(args))
 
-		if cfg.Verbose {
-			log.Println("Removing complete.")
-		}
+		printResults(r)
+
+		logger.Debug("Removing complete")
+
+		exitResults(r)
 	},
 }
 
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -29,12 +30,21 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/borkod/kindly/config"
+	kindly "github.com/borkod/kindly/pkg"
 )
 
 var cfgFile string
 
 var cfg config.Config
 
+// globalCfg is the configuration before the --local flag selects the project-local kindly directory
+var globalCfg config.Config
+
+// logger logs the messages of commands at the level and in the format of the --log-level and --log-format flags
+var logger kindly.Logger
+
+var logLevel kindly.Level
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:     "kindly <command>",
@@ -63,7 +73,22 @@ func init() {
 	// Cobra persistent flags are defined here; global for the application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.kindly/.kindly.yaml)")
-	rootCmd.PersistentFlags().BoolVarP(&cfg.Verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.Verbose, "verbose", "v", false, "Verbose output (same as --log-level debug)")
+	rootCmd.PersistentFlags().StringP("output", "o", outputText, "Output format: text, table, json or yaml")
+	if err := viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().String("log-level", "", "Log level: debug, info, warn or error (default \"info\")")
+	if err := viper.BindPFlag("loglevel", rootCmd.PersistentFlags().Lookup("log-level")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().String("log-format", kindly.LogFormatText, "Log format: text or json")
+	if err := viper.BindPFlag("logformat", rootCmd.PersistentFlags().Lookup("log-format")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	rootCmd.PersistentFlags().StringVar(&cfg.OutBinDir, "OutBinDir", "", "Default binary file output directory (default is $HOME/.kindly/bin/)")
 	if err := viper.BindPFlag("OutBinDir", rootCmd.PersistentFlags().Lookup("OutBinDir")); err != nil {
 		fmt.Println(err)
@@ -84,6 +109,21 @@ func init() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	rootCmd.PersistentFlags().StringVar(&cfg.CacheDir, "CacheDir", "", "Default download cache directory (default is $HOME/.kindly/cache/)")
+	if err := viper.BindPFlag("CacheDir", rootCmd.PersistentFlags().Lookup("CacheDir")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().StringVar(&cfg.PkgDir, "PkgDir", "", "Default directory of installed package versions (default is $HOME/.kindly/pkgs/)")
+	if err := viper.BindPFlag("PkgDir", rootCmd.PersistentFlags().Lookup("PkgDir")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().Bool("local", false, "Use the project-local .kindly directory of the closest kindly.yaml project (default is the current directory)")
+	if err := viper.BindPFlag("local", rootCmd.PersistentFlags().Lookup("local")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	//rootCmd.PersistentFlags().BoolVarP(&cfg.UniqueDir, "unique-directory", "", false, "write files into unique directory (default is false)")
 	//viper.BindPFlag("unique-directory", rootCmd.PersistentFlags().Lookup("unique-directory"))
 	rootCmd.PersistentFlags().StringVar(&cfg.Completion, "completion", "bash", "Completion shell setting")
@@ -91,7 +131,7 @@ func init() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	rootCmd.PersistentFlags().StringVar(&cfg.Source, "Source", "https://raw.githubusercontent.com/borkod/kindly-specs/main/specs/", "Source of package spec files")
+	rootCmd.PersistentFlags().StringVar(&cfg.Source, "Source", kindly.DefaultSource, "Source of package spec files (file://, https:// or github:// location)")
 	if err := viper.BindPFlag("Source", rootCmd.PersistentFlags().Lookup("Source")); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -106,16 +146,90 @@ func init() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	rootCmd.PersistentFlags().DurationVar(&cfg.ConnectTimeout, "ConnectTimeout", 10*time.Second, "HTTP connect timeout")
+	if err := viper.BindPFlag("ConnectTimeout", rootCmd.PersistentFlags().Lookup("ConnectTimeout")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().DurationVar(&cfg.RequestTimeout, "RequestTimeout", 10*time.Minute, "HTTP total request timeout, including the download (0 for no timeout)")
+	if err := viper.BindPFlag("RequestTimeout", rootCmd.PersistentFlags().Lookup("RequestTimeout")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().DurationVar(&cfg.IdleTimeout, "IdleTimeout", 90*time.Second, "HTTP idle connection timeout")
+	if err := viper.BindPFlag("IdleTimeout", rootCmd.PersistentFlags().Lookup("IdleTimeout")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().StringVar(&cfg.Proxy, "Proxy", "", "HTTP proxy URL (default is read from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables)")
+	if err := viper.BindPFlag("Proxy", rootCmd.PersistentFlags().Lookup("Proxy")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().StringVar(&cfg.CABundle, "CABundle", "", "PEM file of CA certificates to trust in addition to the system certificates")
+	if err := viper.BindPFlag("CABundle", rootCmd.PersistentFlags().Lookup("CABundle")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().StringVar(&cfg.ClientCert, "ClientCert", "", "PEM client certificate file for servers that require mutual TLS")
+	if err := viper.BindPFlag("ClientCert", rootCmd.PersistentFlags().Lookup("ClientCert")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().StringVar(&cfg.ClientKey, "ClientKey", "", "PEM client certificate key file for servers that require mutual TLS")
+	if err := viper.BindPFlag("ClientKey", rootCmd.PersistentFlags().Lookup("ClientKey")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().StringVar(&cfg.Netrc, "Netrc", "", "netrc file with credentials for authenticated requests (default is $HOME/.netrc)")
+	if err := viper.BindPFlag("Netrc", rootCmd.PersistentFlags().Lookup("Netrc")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().IntVar(&cfg.Retries, "Retries", 3, "Number of retries for HTTP requests that fail with a server error or connection reset")
+	if err := viper.BindPFlag("Retries", rootCmd.PersistentFlags().Lookup("Retries")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rootCmd.PersistentFlags().DurationVar(&cfg.RetryBackoff, "RetryBackoff", 1*time.Second, "Wait time before the first HTTP retry; doubles after each retry")
+	if err := viper.BindPFlag("RetryBackoff", rootCmd.PersistentFlags().Lookup("RetryBackoff")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	switch viper.GetString("output") {
+	case outputText, outputTable, outputJSON, outputYAML:
+	default:
+		fmt.Fprintln(os.Stderr, "Invalid output format: "+viper.GetString("output"))
+		os.Exit(1)
+	}
+
+	// --verbose is the debug log level unless the log level is set
+	logLevel = kindly.LevelInfo
+	if cfg.Verbose {
+		logLevel = kindly.LevelDebug
+	}
+	if l := viper.GetString("loglevel"); len(l) > 0 {
+		var err error
+		if logLevel, err = kindly.ParseLevel(l); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	var err error
+	if logger, err = kindly.NewLogger(os.Stderr, logLevel, viper.GetString("logformat")); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	// Find home directory.
 	home, err := homedir.Dir()
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		fatal(err)
 	}
 
 	// Initialize default values
@@ -123,6 +237,9 @@ func initConfig() {
 	cfg.OutBinDir = filepath.Join(home, ".kindly", "bin")
 	cfg.OutCompletionDir = filepath.Join(home, ".kindly", "completion")
 	cfg.OutManDir = filepath.Join(home, ".kindly", "man")
+	cfg.CacheDir = filepath.Join(home, ".kindly", "cache")
+	cfg.PkgDir = filepath.Join(home, ".kindly", "pkgs")
+	cfg.Netrc = filepath.Join(home, ".netrc")
 	cfg.OS = runtime.GOOS
 	cfg.Arch = runtime.GOARCH
 
@@ -140,14 +257,54 @@ func initConfig() {
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		logger.Debug("Using config file", "path", viper.ConfigFileUsed())
 	}
 
 	// Update variables based on any flags or environment variables set by the user
 	cfg.ManifestDir = viper.GetString("ManifestDir")
 	cfg.OutBinDir = viper.GetString("OutBinDir")
+	cfg.OutCompletionDir = viper.GetString("OutCompletionDir")
 	//cfg.UniqueDir = viper.GetBool("unique-directory")
 	cfg.OutManDir = viper.GetString("OutManDir")
+	cfg.CacheDir = viper.GetString("CacheDir")
+	cfg.PkgDir = viper.GetString("PkgDir")
+	cfg.Source = viper.GetString("Source")
+	cfg.PublicKey = viper.GetString("PublicKey")
+	cfg.Index = viper.GetString("Index")
+	if err := viper.UnmarshalKey("sources", &cfg.Sources); err != nil {
+		fatal(err)
+	}
 	cfg.OS = viper.GetString("OS")
 	cfg.Arch = viper.GetString("Arch")
+	cfg.ConnectTimeout = viper.GetDuration("ConnectTimeout")
+	cfg.RequestTimeout = viper.GetDuration("RequestTimeout")
+	cfg.IdleTimeout = viper.GetDuration("IdleTimeout")
+	cfg.Retries = viper.GetInt("Retries")
+	cfg.RetryBackoff = viper.GetDuration("RetryBackoff")
+	cfg.Proxy = viper.GetString("Proxy")
+	cfg.CABundle = viper.GetString("CABundle")
+	cfg.ClientCert = viper.GetString("ClientCert")
+	cfg.ClientKey = viper.GetString("ClientKey")
+	cfg.Netrc = viper.GetString("Netrc")
+	if err := viper.UnmarshalKey("credentials", &cfg.Credentials); err != nil {
+		fatal(err)
+	}
+	if err := viper.BindEnv("GithubToken", "KINDLY_GITHUBTOKEN", "GITHUB_TOKEN"); err != nil {
+		fatal(err)
+	}
+	cfg.GithubToken = viper.GetString("GithubToken")
+
+	globalCfg = cfg
+
+	// Use the project-local kindly directory of the closest project
+	if viper.GetBool("local") {
+		dir, err := os.Getwd()
+		if err != nil {
+			fatal(err)
+		}
+		if p, err := kindly.FindProjectFile(dir); err == nil {
+			dir = filepath.Dir(p)
+		}
+		cfg = kindly.ProjectConfig(cfg, dir)
+	}
 }
@@ -0,0 +1,247 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/borkod/kindly/config"
+	kindly "github.com/borkod/kindly/pkg"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// sourceCmd represents the source command
+var sourceCmd = &cobra.Command{
+	Use:   "source",
+	Short: "Manages package spec sources.",
+	Long: `Manages the list of package spec sources.
+
+Sources are searched for packages in priority order.
+Use source/name to install a package from a specific source.
+
+Examples:
+	kindly source add internal github://example/kindly-specs/specs@main
+	kindly source list
+	kindly install internal/mytool
+	kindly source remove internal`,
+}
+
+// sourceAddCmd represents the source add command
+var sourceAddCmd = &cobra.Command{
+	Use:   "add [name] [source]",
+	Short: "Adds a package spec source.",
+	Long: `Adds a named package spec source.
+
+The source is added with the lowest priority unless --priority is set.
+Priority 1 is the highest priority.
+
+Use --public-key to verify the specs of the source against its signed index,
+and --index to verify them against a local copy of the index file.
+
+Examples:
+	kindly source add internal file:///opt/kindly-specs
+	kindly source add internal https://specs.example.com/kindly/ --priority 1
+	kindly source add internal https://specs.example.com/kindly/ --public-key qBxP9LXmhRqODqs1Cr0d/Vb095k684CSJWFCjppaOSY=`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		name := args[0]
+		url := args[1]
+
+		if len(name) == 0 || strings.ContainsAny(name, "/@") {
+			fatal(errors.New("Invalid source name: " + name))
+		}
+		if _, err := kindly.NewSpecSource(url, nil); err != nil {
+			fatal(err)
+		}
+
+		srcs := configuredSources()
+		for _, s := range srcs {
+			if s.Name == name {
+				fatal(errors.New("Source already exists: " + name))
+			}
+		}
+
+		p := viper.GetInt("priority")
+		if p < 1 || p > len(srcs) {
+			p = len(srcs) + 1
+		}
+		s := config.Source{Name: name, URL: url, PublicKey: viper.GetString("sourcepublickey"), Index: viper.GetString("sourceindex")}
+		if len(s.PublicKey) > 0 {
+			if _, err := kindly.ParsePublicKey(s.PublicKey); err != nil {
+				fatal(err)
+			}
+		} else if len(s.Index) > 0 {
+			fatal(errors.New("--index requires --public-key"))
+		}
+		srcs = append(srcs[:p-1], append([]config.Source{s}, srcs[p-1:]...)...)
+
+		if err := writeSources(srcs); err != nil {
+			fatal(err)
+		}
+
+		logger.Debug("Added source", "source", name)
+	},
+}
+
+// sourceRemoveCmd represents the source remove command
+var sourceRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "Removes a package spec source.",
+	Long: `Removes a named package spec source.
+
+Example:
+	kindly source remove internal`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		srcs := configuredSources()
+		for i, s := range srcs {
+			if s.Name == args[0] {
+				if err := writeSources(append(srcs[:i], srcs[i+1:]...)); err != nil {
+					fatal(err)
+				}
+				logger.Debug("Removed source", "source", args[0])
+				return
+			}
+		}
+
+		fatal(errors.New("Unknown source: " + args[0]))
+	},
+}
+
+// sourceListCmd represents the source list command
+var sourceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists package spec sources.",
+	Long: `Lists package spec sources in priority order.
+
+Example:
+	kindly source list`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		srcs := configuredSources()
+
+		rows := make([][]string, 0, len(srcs))
+		for i, s := range srcs {
+			rows = append(rows, []string{strconv.Itoa(i + 1), s.Name, s.URL, describeSource(s)})
+		}
+
+		printOutput(srcs, []string{"PRIORITY", "NAME", "SOURCE", "DESCRIPTION"}, rows, func() {
+			for i, s := range srcs {
+				fmt.Printf("%d\t%s\t%s\t(%s)\n", i+1, s.Name, s.URL, describeSource(s))
+			}
+		})
+	},
+}
+
+// describeSource returns the description of source s, noting if its specs are verified
+func describeSource(s config.Source) string {
+	src, err := kindly.NewSpecSource(s.URL, nil)
+	if err != nil {
+		return "invalid source"
+	}
+	if len(s.PublicKey) > 0 {
+		return src.Describe() + ", signed"
+	}
+	return src.Describe()
+}
+
+// configuredSources returns the configured sources.
+// If no sources are configured, the Source setting is returned as the only source.
+func configuredSources() []config.Source {
+	return kindly.Sources(cfg)
+}
+
+// writeSources saves the sources list in the config file, keeping all other settings
+func writeSources(srcs []config.Source) error {
+	filename := viper.ConfigFileUsed()
+	if filename == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return err
+		}
+		filename = filepath.Join(home, ".kindly", ".kindly.yaml")
+	}
+
+	settings := make(map[string]interface{})
+
+	file, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := yaml.Unmarshal(file, &settings); err != nil {
+		return err
+	}
+
+	// Setting keys are case insensitive
+	for key := range settings {
+		if strings.EqualFold(key, "sources") {
+			delete(settings, key)
+		}
+	}
+	settings["sources"] = srcs
+
+	d, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filename, d, 0644); err != nil {
+		return errors.New("Unable to write config file: " + err.Error())
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(sourceCmd)
+	sourceCmd.AddCommand(sourceAddCmd)
+	sourceCmd.AddCommand(sourceRemoveCmd)
+	sourceCmd.AddCommand(sourceListCmd)
+
+	sourceAddCmd.Flags().IntP("priority", "p", 0, "Priority of the source. 1 is the highest priority. (default is lowest priority)")
+	if err := viper.BindPFlag("priority", sourceAddCmd.Flags().Lookup("priority")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	sourceAddCmd.Flags().String("public-key", "", "Base64 ed25519 public key that signs the index of the source.")
+	if err := viper.BindPFlag("sourcepublickey", sourceAddCmd.Flags().Lookup("public-key")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	sourceAddCmd.Flags().String("index", "", "Local copy of the signed index file of the source.")
+	if err := viper.BindPFlag("sourceindex", sourceAddCmd.Flags().Lookup("index")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Installs the packages of a lockfile.",
+	Long: `Installs the packages of a lockfile at their locked versions.
+Packages that are already installed as locked are skipped.
+
+Use the --frozen flag to require that each package resolves to the locked
+asset URL and SHA256 value. Any mismatch fails the package.
+
+Examples:
+	kindly sync
+	kindly sync --frozen --lockfile tools.lock`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		lf, err := kindly.ReadLockFile(viper.GetString("synclockfile"))
+		if err != nil {
+			fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r := k.Sync(ctx, lf, viper.GetBool("frozen"), viper.GetInt("syncjobs"))
+
+		printResults(r)
+		exitResults(r)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().String("lockfile", kindly.LockFileName, "Lockfile to install.")
+	if err := viper.BindPFlag("synclockfile", syncCmd.Flags().Lookup("lockfile")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	syncCmd.Flags().Bool("frozen", false, "Fail if a package does not resolve to the locked asset URL and SHA256 value.")
+	if err := viper.BindPFlag("frozen", syncCmd.Flags().Lookup("frozen")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	syncCmd.Flags().IntP("jobs", "j", 4, "Number of packages to install at a time.")
+	if err := viper.BindPFlag("syncjobs", syncCmd.Flags().Lookup("jobs")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
@@ -22,9 +22,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 
 	kindly "github.com/borkod/kindly/pkg"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
 )
 
@@ -40,18 +42,16 @@ The command attempts to locate the latest release, detect available OS and archi
 
 The output Kindly YAML spec will require manual review for correctness and additional manual for to make it complete.
 
-Example:
-	kindly template cli cli`,
+Use the --checksums flag to download the release assets and embed their SHA256 values in the spec.
+
+Examples:
+	kindly template cli cli
+	kindly template cli cli --checksums`,
 	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		var k kindly.Kindly
-		k.SetConfig(cfg)
-		k.SetLogger(log.New(os.Stdout, "", log.Ltime))
-		log.SetFlags(log.Ltime)
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
 
-		if cfg.Verbose {
-			log.Println("Generating template.")
-		}
+		logger.Debug("Generating template", "owner", args[0], "repo", args[1])
 
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -59,17 +59,28 @@ Example:
 		owner := args[0]
 		repo := args[1]
 
-		kc, err := k.GenerateTemplate(ctx, owner, repo)
+		kc, err := k.GenerateTemplate(ctx, owner, repo, viper.GetBool("checksums"))
 		if err != nil {
-			log.Println(err)
+			fatal(err)
+		}
 
+		platforms := make([]string, 0, len(kc.Spec.Assets))
+		for p := range kc.Spec.Assets {
+			platforms = append(platforms, p)
 		}
-		d, err := yaml.Marshal(&kc)
-		if err != nil {
-			log.Println("ERROR: ", err)
+		sort.Strings(platforms)
+		rows := make([][]string, 0, len(platforms))
+		for _, p := range platforms {
+			rows = append(rows, []string{p, kc.Spec.Assets[p].URL})
 		}
-		fmt.Printf("---\n%s\n", string(d))
 
+		printOutput(kc, []string{"PLATFORM", "URL"}, rows, func() {
+			d, err := yaml.Marshal(&kc)
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Printf("---\n%s\n", string(d))
+		})
 	},
 }
 
@@ -84,5 +95,9 @@ func init() {
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
-	// templateCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	templateCmd.Flags().BoolP("checksums", "c", false, "Download release assets and embed their SHA256 values.")
+	if err := viper.BindPFlag("checksums", templateCmd.Flags().Lookup("checksums")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
 }
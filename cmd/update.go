@@ -18,7 +18,7 @@ package cmd
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"io/ioutil"
 	"log"
 	"os"
@@ -38,29 +38,32 @@ var updateCmd = &cobra.Command{
 Optionally, use the --all flag to update all installed packages.
 If set, all other arguments are ignored.
 
+Packages installed with a version constraint, such as gh-cli@^1.4,
+are updated to the highest version that satisfies the constraint.
+Use the --latest flag to update to the latest version and drop the constraint.
+
+Packages held with the pin command are skipped.
+
 Examples:
 	kindly update gh-cli
-	kindly update -a`,
+	kindly update -a --jobs 8
+	kindly update --latest gh-cli`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("update called")
-		var k kindly.Kindly
-		k.SetConfig(cfg)
-		k.SetLogger(log.New(os.Stdout, "", log.Ltime))
-		log.SetFlags(log.Ltime)
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
 
 		if !viper.GetBool("updateall") && len(args) == 0 {
-			log.Fatalln("Must provide a package name as an argument.")
+			fatal(errors.New("Must provide a package name as an argument"))
 		}
 
 		if viper.GetBool("updateall") {
 			if len(args) > 0 {
-				log.Println("Remove All flag is set. Ignoring all other arguments.")
+				logger.Warn("All flag is set, ignoring all other arguments")
 			}
 			args = make([]string, 0)
 
 			files, err := ioutil.ReadDir(cfg.ManifestDir)
 			if err != nil {
-				log.Fatalln(err)
+				fatal(err)
 			}
 
 			for _, n := range files {
@@ -69,23 +72,17 @@ Examples:
 				}
 			}
 		}
-		// Iterate over all packages provided as command arguments
-		for _, n := range args {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
-			if cfg.Verbose {
-				log.Println("Updating package: ", n)
-			}
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-			if err := k.Update(ctx, n); err != nil {
-				log.Print(string("\u001b[31m"), err, string("\u001b[0m"), "\n")
-				continue
-			}
-		}
+		// Update packages concurrently, at most --jobs at a time
+		r := k.UpdateAll(ctx, uniqueArgs(args), viper.GetBool("latest"), viper.GetInt("updatejobs"))
 
-		if cfg.Verbose {
-			log.Println("Update complete.")
-		}
+		printResults(r)
+
+		logger.Debug("Update complete")
+
+		exitResults(r)
 	},
 }
 
@@ -105,4 +102,14 @@ func init() {
 		log.Println(err)
 		os.Exit(1)
 	}
+	updateCmd.Flags().Bool("latest", false, "Update to the latest version, ignoring the version constraint the package was installed with.")
+	if err := viper.BindPFlag("latest", updateCmd.Flags().Lookup("latest")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	updateCmd.Flags().IntP("jobs", "j", 4, "Number of packages to update at a time.")
+	if err := viper.BindPFlag("updatejobs", updateCmd.Flags().Lookup("jobs")); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,57 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"context"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/cobra"
+)
+
+// useCmd represents the use command
+var useCmd = &cobra.Command{
+	Use:   "use [name of package]@[version]",
+	Short: "Switches a package to another installed version.",
+	Long: `Switches a package to another installed version.
+
+The binaries, completions and man pages in the output directories are
+linked to the files of the selected version.
+
+Example:
+	kindly use terraform@v1.0.0`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r := []kindly.PackageResult{{Package: args[0], Status: kindly.StatusActive}}
+		if err := k.Use(ctx, args[0]); err != nil {
+			r[0].Fail(err)
+		}
+
+		printResults(r)
+		exitResults(r)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(useCmd)
+}
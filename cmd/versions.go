@@ -0,0 +1,72 @@
+/*
+Copyright © 2021 Borko Djurkovic <borkod@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd is for implementing commands
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	kindly "github.com/borkod/kindly/pkg"
+	"github.com/spf13/cobra"
+)
+
+// versionsCmd represents the versions command
+var versionsCmd = &cobra.Command{
+	Use:   "versions [name of package]",
+	Short: "Lists installed versions of a package.",
+	Long: `Lists installed versions of a package.
+The active version is marked with *.
+
+Example:
+	kindly versions terraform`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		k := kindly.New(kindly.WithConfig(cfg), kindly.WithLogger(logger))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		v, err := k.Versions(ctx, args[0])
+		if err != nil {
+			fatal(err)
+		}
+
+		rows := make([][]string, 0, len(v))
+		for _, p := range v {
+			a := ""
+			if p.Active {
+				a = "*"
+			}
+			rows = append(rows, []string{p.Version, p.Date, a})
+		}
+
+		printOutput(v, []string{"VERSION", "DATE", "ACTIVE"}, rows, func() {
+			for _, p := range v {
+				a := " "
+				if p.Active {
+					a = "*"
+				}
+				fmt.Printf("%s %s\t%s\n", a, p.Version, p.Date)
+			}
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionsCmd)
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // Config struct for kindly
 type Config struct {
 	Verbose bool
@@ -8,8 +10,44 @@ type Config struct {
 	OutBinDir        string
 	OutCompletionDir string
 	OutManDir        string
+	CacheDir         string
+	PkgDir           string
 	Completion       string
 	Source           string
+	Sources          []Source
+	PublicKey        string
+	Index            string
 	OS               string
 	Arch             string
+	ConnectTimeout   time.Duration
+	RequestTimeout   time.Duration
+	IdleTimeout      time.Duration
+	Retries          int
+	RetryBackoff     time.Duration
+	Proxy            string
+	CABundle         string
+	ClientCert       string
+	ClientKey        string
+	Credentials      []Credential
+	Netrc            string
+	GithubToken      string
+}
+
+// Credential authenticates HTTPS requests to Host with a bearer Token, or with Username and Password.
+// Host is a host name, a host:port, *.domain for all sub domains, or * for all hosts.
+type Credential struct {
+	Host     string `mapstructure:"host" yaml:"host"`
+	Token    string `mapstructure:"token" yaml:"token,omitempty"`
+	Username string `mapstructure:"username" yaml:"username,omitempty"`
+	Password string `mapstructure:"password" yaml:"password,omitempty"`
+}
+
+// Source is a named repository of package spec files.
+// If PublicKey is set, specs are verified against the signed index of the source
+// or against the local index file copy in Index.
+type Source struct {
+	Name      string `json:"name" mapstructure:"name" yaml:"name"`
+	URL       string `json:"url" mapstructure:"url" yaml:"url"`
+	PublicKey string `json:"public_key,omitempty" mapstructure:"public_key" yaml:"public_key,omitempty"`
+	Index     string `json:"index,omitempty" mapstructure:"index" yaml:"index,omitempty"`
 }
@@ -17,25 +17,60 @@ limitations under the License.
 // Package pkg is for implementing commands
 package pkg
 
+import (
+	"sort"
+
+	"golang.org/x/mod/semver"
+)
+
 // KindlyStruct is exported.
 type KindlyStruct struct {
 	Spec struct {
-		Name        string              `yaml:"name"`
-		Description string              `yaml:"description"`
-		Homepage    string              `yaml:"homepage"`
-		RepoURL     string              `yaml:"repo_url"`
-		License     string              `yaml:"license"`
-		Tags        []string            `yaml:"tags"`
-		Version     string              `yaml:"version"`
-		Assets      map[string]Asset    `yaml:"assets"`
-		Bin         []string            `yaml:"bin"`
-		Completion  map[string][]string `yaml:"completion"`
-		Man         []string            `yaml:"man"`
-	}
+		Name        string              `json:"name" yaml:"name"`
+		Description string              `json:"description" yaml:"description"`
+		Homepage    string              `json:"homepage" yaml:"homepage"`
+		RepoURL     string              `json:"repo_url" yaml:"repo_url"`
+		License     string              `json:"license" yaml:"license"`
+		Tags        []string            `json:"tags" yaml:"tags"`
+		Version     string              `json:"version" yaml:"version"`
+		Assets      map[string]Asset    `json:"assets" yaml:"assets"`
+		Versions    []SpecVersion       `json:"versions,omitempty" yaml:"versions,omitempty"`
+		Bin         []string            `json:"bin" yaml:"bin"`
+		Completion  map[string][]string `json:"completion" yaml:"completion"`
+		Man         []string            `json:"man" yaml:"man"`
+	} `json:"spec" yaml:"spec"`
 }
 
 // Asset is exported.
+// Sha256 and Sha512 pin the checksum of the asset for the spec version.
+// If set they are verified in preference to the ShaURL file.
 type Asset struct {
-	URL    string `yaml:"url"`
-	ShaURL string `yaml:"sha_url"`
+	URL    string `json:"url" yaml:"url"`
+	ShaURL string `json:"sha_url" yaml:"sha_url"`
+	Sha256 string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	Sha512 string `json:"sha512,omitempty" yaml:"sha512,omitempty"`
+}
+
+// SpecVersion is exported.
+// It lists an older version of the package that can be installed.
+// Assets override the spec assets of the same OS and architecture for this version.
+type SpecVersion struct {
+	Version string           `json:"version" yaml:"version"`
+	Assets  map[string]Asset `json:"assets,omitempty" yaml:"assets,omitempty"`
+}
+
+// AvailableVersions returns the versions of the package that can be installed, from the newest to the oldest
+func (ks KindlyStruct) AvailableVersions() []string {
+	v := []string{ks.Spec.Version}
+	for _, sv := range ks.Spec.Versions {
+		if semver.Compare(sv.Version, ks.Spec.Version) != 0 {
+			v = append(v, sv.Version)
+		}
+	}
+
+	sort.Slice(v, func(i, j int) bool {
+		return semver.Compare(v[i], v[j]) > 0
+	})
+
+	return v
 }
@@ -0,0 +1,220 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// blobDir is the cache sub directory that keeps downloaded files by SHA256 value
+const blobDir = "sha256"
+
+// dateFormat is the format of dates recorded by kindly
+const dateFormat = "2006-01-02 15:04:05"
+
+// CacheEntry describes a downloaded file kept in the cache
+type CacheEntry struct {
+	Sha256 string `json:"sha256" yaml:"sha256"`
+	URL    string `json:"url" yaml:"url"`
+	Size   int64  `json:"size" yaml:"size"`
+	Date   string `json:"date" yaml:"date"`
+}
+
+// CacheList returns the files kept in the download cache
+func (k Kindly) CacheList() (c []CacheEntry, err error) {
+	if len(k.cfg.CacheDir) == 0 {
+		return c, nil
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(k.cfg.CacheDir, blobDir))
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return c, err
+	}
+
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".yaml") {
+			continue
+		}
+		e, err := readCacheEntry(filepath.Join(k.cfg.CacheDir, blobDir, f.Name()))
+		if err != nil {
+			return c, err
+		}
+		c = append(c, e)
+	}
+
+	return c, nil
+}
+
+// CacheClean deletes all files in the download cache, including partial downloads
+func (k Kindly) CacheClean() error {
+	if len(k.cfg.CacheDir) == 0 {
+		return nil
+	}
+
+	for _, d := range []string{blobDir, partialDir} {
+		k.logger.Debug("Deleting directory", "path", filepath.Join(k.cfg.CacheDir, d))
+		if err := os.RemoveAll(filepath.Join(k.cfg.CacheDir, d)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CachePrune deletes the cached files that were last used longer than d ago and returns them
+func (k Kindly) CachePrune(d time.Duration) (p []CacheEntry, err error) {
+	c, err := k.CacheList()
+	if err != nil {
+		return p, err
+	}
+
+	for _, e := range c {
+		t, err := time.ParseInLocation(dateFormat, e.Date, time.Local)
+		if err == nil && time.Since(t) < d {
+			continue
+		}
+
+		k.logger.Debug("Deleting file", "path", k.blobPath(e.Sha256))
+		if err := os.Remove(k.blobPath(e.Sha256)); err != nil && !os.IsNotExist(err) {
+			return p, err
+		}
+		if err := os.Remove(k.blobPath(e.Sha256) + ".yaml"); err != nil {
+			return p, err
+		}
+		p = append(p, e)
+	}
+
+	return p, nil
+}
+
+// blobPath returns the cache path of the file with SHA256 value sum
+func (k Kindly) blobPath(sum string) string {
+	return filepath.Join(k.cfg.CacheDir, blobDir, strings.ToLower(sum))
+}
+
+// cacheLookup returns the cached file with SHA256 value sum.
+// If sum is not known, the cached file downloaded from url is returned.
+func (k Kindly) cacheLookup(sum string, url string) (CacheEntry, bool) {
+	if len(k.cfg.CacheDir) == 0 {
+		return CacheEntry{}, false
+	}
+
+	if len(sum) > 0 {
+		e, err := readCacheEntry(k.blobPath(sum) + ".yaml")
+		if err != nil {
+			return e, false
+		}
+		// Check that the cached file is intact
+		if s, err := hashFileHex(k.blobPath(sum)); err != nil || !strings.EqualFold(s, sum) {
+			return e, false
+		}
+		return e, true
+	}
+
+	c, err := k.CacheList()
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	for _, e := range c {
+		if e.URL == url {
+			if s, err := hashFileHex(k.blobPath(e.Sha256)); err == nil && s == e.Sha256 {
+				return e, true
+			}
+		}
+	}
+
+	return CacheEntry{}, false
+}
+
+// cacheStore moves a downloaded file with SHA256 value sum into the cache
+// and returns the path of the cached file
+func (k Kindly) cacheStore(src string, sum string, url string) (string, error) {
+	if len(k.cfg.CacheDir) == 0 {
+		return src, nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(k.cfg.CacheDir, blobDir), os.ModePerm); err != nil {
+		return src, err
+	}
+
+	fi, err := os.Stat(src)
+	if err != nil {
+		return src, err
+	}
+
+	if err := moveFile(k.blobPath(sum), src); err != nil {
+		return src, err
+	}
+
+	e := CacheEntry{Sha256: strings.ToLower(sum), URL: url, Size: fi.Size()}
+	return k.blobPath(sum), k.touchCacheEntry(e)
+}
+
+// touchCacheEntry records entry e as used now
+func (k Kindly) touchCacheEntry(e CacheEntry) error {
+	e.Date = time.Now().Format(dateFormat)
+
+	file, err := yaml.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(k.blobPath(e.Sha256)+".yaml", file, 0644)
+}
+
+func readCacheEntry(filename string) (e CacheEntry, err error) {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return e, err
+	}
+	if err = yaml.Unmarshal(file, &e); err != nil {
+		return e, err
+	}
+	if len(e.Sha256) == 0 {
+		return e, errors.New("Invalid cache entry: " + filename)
+	}
+	return e, nil
+}
+
+// hashFileHex returns the hex encoded SHA256 value of a file
+func hashFileHex(filename string) (string, error) {
+	hash := sha256.New()
+	if _, err := hashFile(filename, hash); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// linkFile hard links file src to dst, copying it if a link is not possible
+func linkFile(dst string, src string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
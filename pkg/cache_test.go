@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// cacheFile stores a file with contents s downloaded from url in the download cache and returns its SHA256 value
+func cacheFile(t *testing.T, k *Kindly, s string, url string) string {
+	src := filepath.Join(t.TempDir(), "download")
+	writeFile(t, src, s)
+	sum := sha256Hex([]byte(s))
+	if _, err := k.cacheStore(src, sum, url); err != nil {
+		t.Fatal(err)
+	}
+	return sum
+}
+
+func TestCacheLookup(t *testing.T) {
+	k := New(WithConfig(DefaultConfig()), WithRoot(t.TempDir()))
+	sum := cacheFile(t, k, "foo v1", "https://example.com/foo.tar.gz")
+
+	tests := []struct {
+		name string
+		sum  string
+		url  string
+		want bool
+	}{
+		{"sha256", sum, "", true},
+		{"upper case sha256", strings.ToUpper(sum), "", true},
+		{"other sha256", strings.Repeat("0", 64), "https://example.com/foo.tar.gz", false},
+		{"url", "", "https://example.com/foo.tar.gz", true},
+		{"other url", "", "https://example.com/bar.tar.gz", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := k.cacheLookup(tt.sum, tt.url)
+			if ok != tt.want {
+				t.Fatalf("cacheLookup() ok = %v, want %v", ok, tt.want)
+			}
+			if ok && e.Sha256 != sum {
+				t.Errorf("cacheLookup() = %+v, want entry %s", e, sum)
+			}
+		})
+	}
+
+	// A cached file that is no longer intact is not used
+	writeFile(t, k.blobPath(sum), "tampered")
+	if _, ok := k.cacheLookup(sum, ""); ok {
+		t.Error("cacheLookup() of a corrupt file ok = true, want false")
+	}
+	if _, ok := k.cacheLookup("", "https://example.com/foo.tar.gz"); ok {
+		t.Error("cacheLookup() by url of a corrupt file ok = true, want false")
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	k := New(WithConfig(DefaultConfig()), WithRoot(t.TempDir()))
+	oldSum := cacheFile(t, k, "foo v1", "https://example.com/foo_v1.tar.gz")
+	newSum := cacheFile(t, k, "foo v2", "https://example.com/foo_v2.tar.gz")
+
+	// The first file was last used two days ago
+	e, err := readCacheEntry(k.blobPath(oldSum) + ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Date = time.Now().Add(-48 * time.Hour).Format(dateFormat)
+	b, err := yaml.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(k.blobPath(oldSum)+".yaml", b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := k.CachePrune(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("CachePrune() error = %v", err)
+	}
+	if len(p) != 1 || p[0].Sha256 != oldSum {
+		t.Fatalf("CachePrune() = %+v, want entry %s", p, oldSum)
+	}
+
+	for _, f := range []string{k.blobPath(oldSum), k.blobPath(oldSum) + ".yaml"} {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("%s exists after CachePrune(), want none", f)
+		}
+	}
+	if _, ok := k.cacheLookup(newSum, ""); !ok {
+		t.Errorf("cacheLookup() of the recently used file ok = false, want true")
+	}
+}
@@ -4,13 +4,49 @@ import (
 	"context"
 )
 
+// CheckResult is the outcome of the check command for one package
+type CheckResult struct {
+	Package  string        `json:"package" yaml:"package"`
+	Version  string        `json:"version,omitempty" yaml:"version,omitempty"`
+	Status   string        `json:"status" yaml:"status"`
+	Versions []string      `json:"versions,omitempty" yaml:"versions,omitempty"`
+	Error    string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Err      error         `json:"-" yaml:"-"`
+	Spec     *KindlyStruct `json:"spec,omitempty" yaml:"spec,omitempty"`
+}
+
 // Check function checks if the packages passed in args are available TODO variadic function
 func (k Kindly) Check(ctx context.Context, n string) (ks KindlyStruct, err error) {
 
-	_, yc, err := k.getValidYConfig(ctx, n, false, false)
+	_, yc, err := k.getValidYConfig(ctx, InstallRequest{Ref: n})
 	if err != nil {
 		return yc, err
 	}
 
 	return yc, nil
 }
+
+// CheckAll checks if packages are available and returns the results in the order of the packages
+func (k Kindly) CheckAll(ctx context.Context, names []string) []CheckResult {
+	r := make([]CheckResult, 0, len(names))
+
+	for _, n := range names {
+		c := CheckResult{Package: n, Status: StatusAvailable}
+
+		dl, yc, err := k.getValidYConfig(ctx, InstallRequest{Ref: n})
+		if err != nil {
+			c.Status = StatusFailed
+			c.Err = err
+			c.Error = err.Error()
+			r = append(r, c)
+			continue
+		}
+
+		c.Version = dl.Version
+		c.Versions = yc.AvailableVersions()
+		c.Spec = &yc
+		r = append(r, c)
+	}
+
+	return r
+}
@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// comparator is a single version comparison of a constraint, such as >=v1.2.0
+type comparator struct {
+	op      string
+	version string
+}
+
+// constraint is a set of comparators that a version must all satisfy
+type constraint []comparator
+
+// isConstraint reports whether version argument s is a range constraint rather than an exact version
+func isConstraint(s string) bool {
+	return strings.ContainsAny(strings.TrimSpace(s), "^~<>= ")
+}
+
+// canonicalVersion returns version s in canonical semver form, such as v1.2.3 for 1.2.3,
+// or an empty string if s is not a valid version
+func canonicalVersion(s string) string {
+	if !strings.HasPrefix(s, "v") {
+		s = "v" + s
+	}
+	return semver.Canonical(s)
+}
+
+// constraintOps are the operators of constraint comparators, longest first
+var constraintOps = []string{">=", "<=", ">", "<", "=", "^", "~"}
+
+// parseConstraint parses a constraint such as ^1.4, ~2.1.0, ">=1.2 <2" or ">= 1.2".
+// Comparators separated by spaces must all be satisfied.
+func parseConstraint(s string) (c constraint, err error) {
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+
+		op := ""
+		for _, o := range constraintOps {
+			if strings.HasPrefix(f, o) {
+				op = o
+				break
+			}
+		}
+
+		// An operator may be separated from its version by spaces
+		if f == op && i+1 < len(fields) {
+			i++
+			f += fields[i]
+		}
+
+		v := strings.TrimPrefix(strings.TrimPrefix(f, op), "v")
+		parts := strings.Split(v, ".")
+		if len(parts) > 3 || !semver.IsValid("v"+v) {
+			return c, errors.New("Invalid version constraint: " + s)
+		}
+		nums := make([]int, 3)
+		for j, p := range parts {
+			if nums[j], err = strconv.Atoi(p); err != nil {
+				return c, errors.New("Invalid version constraint: " + s)
+			}
+		}
+		lower := semver.Canonical("v" + v)
+
+		switch op {
+		case "^":
+			// Allow changes that do not modify the left-most non-zero part
+			upper := ""
+			switch {
+			case nums[0] > 0 || len(parts) == 1:
+				upper = version(nums[0]+1, 0, 0)
+			case nums[1] > 0 || len(parts) == 2:
+				upper = version(0, nums[1]+1, 0)
+			default:
+				upper = version(0, 0, nums[2]+1)
+			}
+			c = append(c, comparator{">=", lower}, comparator{"<", upper})
+		case "~":
+			// Allow patch changes, or minor changes if only the major version is given
+			upper := version(nums[0], nums[1]+1, 0)
+			if len(parts) == 1 {
+				upper = version(nums[0]+1, 0, 0)
+			}
+			c = append(c, comparator{">=", lower}, comparator{"<", upper})
+		case "":
+			c = append(c, comparator{"=", lower})
+		default:
+			c = append(c, comparator{op, lower})
+		}
+	}
+
+	if len(c) == 0 {
+		return c, errors.New("Invalid version constraint: " + s)
+	}
+
+	return c, nil
+}
+
+// check reports whether version v satisfies the constraint
+func (c constraint) check(v string) bool {
+	for _, cmp := range c {
+		r := semver.Compare(v, cmp.version)
+		ok := false
+		switch cmp.op {
+		case ">=":
+			ok = r >= 0
+		case "<=":
+			ok = r <= 0
+		case ">":
+			ok = r > 0
+		case "<":
+			ok = r < 0
+		default:
+			ok = r == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// latest returns the highest of versions that satisfies the constraint
+func (c constraint) latest(versions []string) (string, bool) {
+	best := ""
+	for _, v := range versions {
+		if c.check(v) && (len(best) == 0 || semver.Compare(v, best) > 0) {
+			best = v
+		}
+	}
+	return best, len(best) > 0
+}
+
+func version(major int, minor int, patch int) string {
+	return "v" + strconv.Itoa(major) + "." + strconv.Itoa(minor) + "." + strconv.Itoa(patch)
+}
@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsConstraint(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"v1.2.3", false},
+		{"1.2.3", false},
+		{"^1.2", true},
+		{"~1.2", true},
+		{">=1.2", true},
+		{">= 1.2", true},
+		{"=1.2.3", true},
+	}
+
+	for _, tt := range tests {
+		if got := isConstraint(tt.s); got != tt.want {
+			t.Errorf("isConstraint(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalVersion(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"v1.2.3", "v1.2.3"},
+		{"1.2.3", "v1.2.3"},
+		{"1.2", "v1.2.0"},
+		{"1.2.3-rc.1", "v1.2.3-rc.1"},
+		{"latest", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := canonicalVersion(tt.s); got != tt.want {
+			t.Errorf("canonicalVersion(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    constraint
+		wantErr bool
+	}{
+		{"^1.4", constraint{{">=", "v1.4.0"}, {"<", "v2.0.0"}}, false},
+		{"^0.4.2", constraint{{">=", "v0.4.2"}, {"<", "v0.5.0"}}, false},
+		{"^0.0.3", constraint{{">=", "v0.0.3"}, {"<", "v0.0.4"}}, false},
+		{"^0", constraint{{">=", "v0.0.0"}, {"<", "v1.0.0"}}, false},
+		{"~2.1.0", constraint{{">=", "v2.1.0"}, {"<", "v2.2.0"}}, false},
+		{"~2", constraint{{">=", "v2.0.0"}, {"<", "v3.0.0"}}, false},
+		{">=1.2 <2", constraint{{">=", "v1.2.0"}, {"<", "v2.0.0"}}, false},
+		{">= 1.2", constraint{{">=", "v1.2.0"}}, false},
+		{">= 1.2 < 2", constraint{{">=", "v1.2.0"}, {"<", "v2.0.0"}}, false},
+		{" >=v1.2.3 ", constraint{{">=", "v1.2.3"}}, false},
+		{"=1.2.3", constraint{{"=", "v1.2.3"}}, false},
+		{"1.2.3", constraint{{"=", "v1.2.3"}}, false},
+		{"", nil, true},
+		{">=", nil, true},
+		{">= <2", nil, true},
+		{"^1.2.3.4", nil, true},
+		{"^x", nil, true},
+		{"~1.2-beta", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseConstraint(tt.s)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseConstraint(%q) = %v, want error", tt.s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseConstraint(%q) error = %v", tt.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseConstraint(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestConstraintLatest(t *testing.T) {
+	versions := []string{"v2.1.0", "v2.0.0", "v1.9.2", "v1.4.0", "v1.3.9", "v0.5.0"}
+
+	tests := []struct {
+		s      string
+		want   string
+		wantOk bool
+	}{
+		{"^1.4", "v1.9.2", true},
+		{"~1.3", "v1.3.9", true},
+		{">= 1.2 < 2", "v1.9.2", true},
+		{">2", "v2.1.0", true},
+		{"<=1.4.0", "v1.4.0", true},
+		{"^0.5", "v0.5.0", true},
+		{"=2.0.0", "v2.0.0", true},
+		{"^3", "", false},
+		{">2.1.0 <2.0.0", "", false},
+	}
+
+	for _, tt := range tests {
+		c, err := parseConstraint(tt.s)
+		if err != nil {
+			t.Fatalf("parseConstraint(%q) error = %v", tt.s, err)
+		}
+		got, ok := c.latest(versions)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("latest(%q) = %q, %v, want %q, %v", tt.s, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/borkod/kindly/config"
+)
+
+// githubHosts are the hosts that receive the GitHub token.
+// Release downloads redirect to signed URLs on other hosts, which must not receive it.
+var githubHosts = []string{"github.com", "api.github.com", "raw.githubusercontent.com"}
+
+// loadCredentials returns the credentials for authenticated requests in priority order:
+// credentials from the config, then ~/.netrc entries, then the GitHub token for GitHub hosts
+func loadCredentials(c config.Config) ([]config.Credential, error) {
+	creds := append([]config.Credential{}, c.Credentials...)
+
+	if len(c.Netrc) > 0 {
+		n, err := readNetrc(expandPath(c.Netrc))
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, n...)
+	}
+
+	if len(c.GithubToken) > 0 {
+		for _, h := range githubHosts {
+			creds = append(creds, config.Credential{Host: h, Token: c.GithubToken})
+		}
+	}
+
+	return creds, nil
+}
+
+// readNetrc returns the machine entries of a netrc file.
+// A missing file has no entries. The default entry is ignored, as it would send its login to every host.
+func readNetrc(filename string) (creds []config.Credential, err error) {
+	file, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return creds, nil
+	} else if err != nil {
+		return creds, err
+	}
+
+	var cur *config.Credential
+	var fields []string
+	macro := false
+
+	for _, line := range strings.Split(string(file), "\n") {
+		// Macro definitions end at an empty line
+		if macro {
+			macro = len(strings.TrimSpace(line)) > 0
+			continue
+		}
+
+		for _, f := range strings.Fields(line) {
+			if f == "macdef" {
+				macro = true
+				break
+			}
+			fields = append(fields, f)
+		}
+	}
+
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine", "default":
+			if cur != nil && len(cur.Host) > 0 {
+				creds = append(creds, *cur)
+			}
+			cur = &config.Credential{}
+			if fields[i] == "machine" {
+				if i+1 >= len(fields) {
+					return creds, errors.New("Invalid netrc file: " + filename)
+				}
+				i++
+				cur.Host = fields[i]
+			}
+		case "login", "password", "account":
+			if cur == nil || i+1 >= len(fields) {
+				return creds, errors.New("Invalid netrc file: " + filename)
+			}
+			i++
+			if fields[i-1] == "login" {
+				cur.Username = fields[i]
+			} else if fields[i-1] == "password" {
+				cur.Password = fields[i]
+			}
+		}
+	}
+	if cur != nil && len(cur.Host) > 0 {
+		creds = append(creds, *cur)
+	}
+
+	return creds, nil
+}
+
+// matchHost reports whether credential host pattern p applies to host h.
+// A pattern of * matches every host and a *. prefix matches all sub domains.
+func matchHost(p string, h string) bool {
+	switch {
+	case p == "*":
+		return true
+	case strings.HasPrefix(p, "*."):
+		return strings.HasSuffix(strings.ToLower(h), strings.ToLower(p[1:]))
+	}
+	return strings.EqualFold(p, h)
+}
+
+// authTransport adds the Authorization header of the first matching credential to HTTPS requests.
+// Requests of redirects to another host are sent without credentials.
+type authTransport struct {
+	next  http.RoundTripper
+	creds []config.Credential
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" || len(req.Header.Get("Authorization")) > 0 || redirectedHost(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	for _, c := range t.creds {
+		if !matchHost(c.Host, req.URL.Hostname()) && !matchHost(c.Host, req.URL.Host) {
+			continue
+		}
+
+		// RoundTrippers must not modify the request
+		r := req.Clone(req.Context())
+		if len(c.Token) > 0 {
+			r.Header.Set("Authorization", "Bearer "+c.Token)
+		} else {
+			r.SetBasicAuth(c.Username, c.Password)
+		}
+		return t.next.RoundTrip(r)
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// redirectedHost reports whether request req follows redirects from a request to another host
+func redirectedHost(req *http.Request) bool {
+	first := req
+	for first.Response != nil && first.Response.Request != nil {
+		first = first.Response.Request
+	}
+	return !strings.EqualFold(first.URL.Host, req.URL.Host)
+}
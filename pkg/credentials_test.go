@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/borkod/kindly/config"
+)
+
+func TestReadNetrc(t *testing.T) {
+	tests := []struct {
+		name    string
+		netrc   string
+		want    []config.Credential
+		wantErr bool
+	}{
+		{
+			"machines",
+			"machine example.com login alice password secret\nmachine other.com\n  login bob\n  password hunter2\n",
+			[]config.Credential{{Host: "example.com", Username: "alice", Password: "secret"}, {Host: "other.com", Username: "bob", Password: "hunter2"}},
+			false,
+		},
+		{
+			"default is ignored",
+			"machine example.com login alice password secret\ndefault login anon password guest\n",
+			[]config.Credential{{Host: "example.com", Username: "alice", Password: "secret"}},
+			false,
+		},
+		{
+			"account is ignored",
+			"machine example.com login alice account acct password secret\n",
+			[]config.Credential{{Host: "example.com", Username: "alice", Password: "secret"}},
+			false,
+		},
+		{
+			"macro",
+			"machine example.com login alice password secret\nmacdef init\ncd /pub\nmachine inside.macro\n\nmachine other.com login bob password hunter2\n",
+			[]config.Credential{{Host: "example.com", Username: "alice", Password: "secret"}, {Host: "other.com", Username: "bob", Password: "hunter2"}},
+			false,
+		},
+		{"empty", "", nil, false},
+		{"machine without host", "machine", nil, true},
+		{"login without machine", "login alice", nil, true},
+		{"password without value", "machine example.com password", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), ".netrc")
+			writeFile(t, filename, tt.netrc)
+
+			got, err := readNetrc(filename)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readNetrc() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readNetrc() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readNetrc() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadNetrcMissing(t *testing.T) {
+	got, err := readNetrc(filepath.Join(t.TempDir(), ".netrc"))
+	if err != nil || len(got) > 0 {
+		t.Errorf("readNetrc() = %+v, %v, want no entries", got, err)
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		want    bool
+	}{
+		{"*", "example.com", true},
+		{"example.com", "example.com", true},
+		{"example.com", "EXAMPLE.com", true},
+		{"example.com", "api.example.com", false},
+		{"*.example.com", "api.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "badexample.com", false},
+		{"*.Example.com", "API.example.COM", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchHost(tt.pattern, tt.host); got != tt.want {
+			t.Errorf("matchHost(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestAuthTransportRedirect(t *testing.T) {
+	auth := make(map[string]string)
+
+	cdn := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth["cdn"] = r.Header.Get("Authorization")
+	}))
+	defer cdn.Close()
+
+	origin := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth["origin"] = r.Header.Get("Authorization")
+		http.Redirect(w, r, cdn.URL+"/asset", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	// Both servers are on 127.0.0.1 with different ports
+	client := &http.Client{Transport: &authTransport{
+		next:  origin.Client().Transport,
+		creds: []config.Credential{{Host: "127.0.0.1", Token: "s3cr3t"}},
+	}}
+
+	resp, err := client.Get(origin.URL + "/release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if auth["origin"] != "Bearer s3cr3t" {
+		t.Errorf("origin Authorization = %q, want %q", auth["origin"], "Bearer s3cr3t")
+	}
+	if len(auth["cdn"]) > 0 {
+		t.Errorf("redirected Authorization = %q, want none", auth["cdn"])
+	}
+}
@@ -0,0 +1,284 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// partialDir is the cache sub directory that keeps partial downloads
+const partialDir = "partial"
+
+// partialMeta records the validators of a partial download,
+// used to check that the remote file has not changed before resuming it
+type partialMeta struct {
+	URL          string `yaml:"url"`
+	ETag         string `yaml:"etag,omitempty"`
+	LastModified string `yaml:"last_modified,omitempty"`
+}
+
+// urlLocks serializes the downloads of a URL between concurrent installs,
+// which share the partial download file and the cache entry of the URL
+var urlLocks = struct {
+	sync.Mutex
+	m map[string]*sync.Mutex
+}{m: make(map[string]*sync.Mutex)}
+
+// lockURL locks url and returns the function that unlocks it
+func lockURL(url string) func() {
+	urlLocks.Lock()
+	mu, ok := urlLocks.m[url]
+	if !ok {
+		mu = &sync.Mutex{}
+		urlLocks.m[url] = mu
+	}
+	urlLocks.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// errRestart is returned by downloadPart when the server sent the complete file instead of the requested range
+var errRestart = errors.New("download restarted")
+
+// downloadFile downloads url into the partial downloads directory and returns the path of the complete file.
+// Partial downloads are kept in the cache directory keyed by URL, and are resumed with Range requests
+// when the server supports them and the ETag or Last-Modified value has not changed.
+// Every downloaded byte is written to the hashes, which are reset if the download starts over.
+// If progress is set, it is called with the downloaded and total bytes as the download proceeds.
+func (k Kindly) downloadFile(ctx context.Context, url string, tmpDir string, progress func(n int64, total int64), hashes ...hash.Hash) (string, error) {
+	dir := tmpDir
+	if len(k.cfg.CacheDir) > 0 {
+		dir = filepath.Join(k.cfg.CacheDir, partialDir)
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	key := sha256Hex([]byte(url))
+	partPath := filepath.Join(dir, key)
+	metaPath := partPath + ".yaml"
+
+	// Hash the bytes already downloaded by a previous attempt
+	var offset int64
+	meta, err := readPartialMeta(metaPath)
+	if err == nil && meta.URL == url {
+		if offset, err = hashFile(partPath, hashes...); err != nil {
+			offset = 0
+		}
+	}
+	if offset == 0 {
+		resetHashes(hashes...)
+		meta = partialMeta{URL: url}
+	}
+
+	for i := 0; ; i++ {
+		if offset > 0 {
+			k.logger.Debug("Resuming download", "url", url, "offset", offset)
+		}
+
+		var n int64
+		n, meta, err = k.downloadPart(ctx, url, partPath, metaPath, offset, meta, progress, hashes...)
+		if err == nil {
+			os.Remove(metaPath)
+			return partPath, nil
+		}
+
+		if errors.Is(err, errRestart) {
+			offset = 0
+			continue
+		}
+
+		// The server does not support resuming this download
+		if len(meta.ETag) == 0 && len(meta.LastModified) == 0 {
+			os.Remove(partPath)
+			os.Remove(metaPath)
+			return "", err
+		}
+
+		// Resume right away while retries are left; otherwise keep the partial file for the next run
+		if n == 0 || i >= k.cfg.Retries || ctx.Err() != nil {
+			return "", fmt.Errorf("download interrupted, run the command again to resume: %w", err)
+		}
+		resetHashes(hashes...)
+		if offset, err = hashFile(partPath, hashes...); err != nil {
+			return "", err
+		}
+	}
+}
+
+// downloadPart requests url from offset and appends the response to partPath.
+// It returns the number of bytes written and the validators of the remote file.
+func (k Kindly) downloadPart(ctx context.Context, url string, partPath string, metaPath string, offset int64, meta partialMeta, progress func(n int64, total int64), hashes ...hash.Hash) (int64, partialMeta, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, meta, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		if len(meta.ETag) > 0 {
+			req.Header.Set("If-Range", meta.ETag)
+		} else if len(meta.LastModified) > 0 {
+			req.Header.Set("If-Range", meta.LastModified)
+		}
+	}
+
+	resp, err := k.httpClient().Do(req)
+	if err != nil {
+		return 0, meta, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	start := int64(0)
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		flags |= os.O_APPEND
+		start = offset
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is not a prefix of the remote file; start over
+		os.Remove(partPath)
+		resetHashes(hashes...)
+		return 0, partialMeta{URL: url}, errRestart
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			// The remote file changed or the server ignored the range; start over
+			resetHashes(hashes...)
+			k.logger.Debug("Unable to resume download, starting over", "url", url)
+		}
+		flags |= os.O_TRUNC
+		meta = partialMeta{URL: url}
+		if resp.Header.Get("Accept-Ranges") == "bytes" {
+			meta.ETag = resp.Header.Get("ETag")
+			meta.LastModified = resp.Header.Get("Last-Modified")
+		}
+		if err := writePartialMeta(metaPath, meta); err != nil {
+			return 0, meta, err
+		}
+	default:
+		os.Remove(partPath)
+		os.Remove(metaPath)
+		return 0, partialMeta{URL: url}, statusError(url, resp)
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return 0, meta, err
+	}
+	defer out.Close()
+
+	w := make([]io.Writer, 0, len(hashes))
+	for _, h := range hashes {
+		w = append(w, h)
+	}
+
+	var body io.Reader = resp.Body
+	if progress != nil {
+		total := int64(-1)
+		if resp.ContentLength >= 0 {
+			total = start + resp.ContentLength
+		}
+		body = &progressReader{r: body, n: start, total: total, progress: progress}
+	}
+
+	n, err := io.Copy(out, io.TeeReader(body, io.MultiWriter(w...)))
+	if err != nil {
+		return n, meta, err
+	}
+
+	return n, meta, out.Close()
+}
+
+// removePartial deletes the partial download of url
+func (k Kindly) removePartial(url string) {
+	if len(k.cfg.CacheDir) == 0 {
+		return
+	}
+	partPath := filepath.Join(k.cfg.CacheDir, partialDir, sha256Hex([]byte(url)))
+	os.Remove(partPath)
+	os.Remove(partPath + ".yaml")
+}
+
+func readPartialMeta(filename string) (meta partialMeta, err error) {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return meta, err
+	}
+	err = yaml.Unmarshal(file, &meta)
+	return meta, err
+}
+
+func writePartialMeta(filename string, meta partialMeta) error {
+	file, err := yaml.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, file, 0644)
+}
+
+// hashFile writes the contents of a file to the hashes and returns its size
+func hashFile(filename string, hashes ...hash.Hash) (int64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := make([]io.Writer, 0, len(hashes))
+	for _, h := range hashes {
+		w = append(w, h)
+	}
+
+	n, err := io.Copy(io.MultiWriter(w...), f)
+	if err != nil {
+		resetHashes(hashes...)
+		return 0, err
+	}
+	return n, nil
+}
+
+func resetHashes(hashes ...hash.Hash) {
+	for _, h := range hashes {
+		h.Reset()
+	}
+}
+
+// moveFile moves file src to dst, copying it if a rename is not possible
+func moveFile(dst string, src string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	in.Close()
+	return os.Remove(src)
+}
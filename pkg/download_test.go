@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadFileResume(t *testing.T) {
+	v1 := []byte(strings.Repeat("kindly v1 ", 100))
+	v2 := []byte(strings.Repeat("kindly v2 ", 100))
+
+	tests := []struct {
+		name     string
+		etag     string
+		contents []byte
+		ranges   bool
+	}{
+		{"206 resumes the partial file", `"v1"`, v1, true},
+		{"200 without range support starts over", `"v1"`, v1, false},
+		{"changed ETag starts over", `"v2"`, v2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRange, gotIfRange string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotRange = r.Header.Get("Range")
+				gotIfRange = r.Header.Get("If-Range")
+				w.Header().Set("ETag", tt.etag)
+				if !tt.ranges {
+					w.Write(tt.contents)
+					return
+				}
+				// ServeContent answers a Range request with 206, or with 200 if If-Range does not match the ETag
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(tt.contents))
+			}))
+			defer srv.Close()
+			k := testKindly(t, srv)
+			url := srv.URL + "/foo.tar.gz"
+
+			// A previous attempt downloaded the first half of v1
+			dir := filepath.Join(k.cfg.CacheDir, partialDir)
+			if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			partPath := filepath.Join(dir, sha256Hex([]byte(url)))
+			if err := ioutil.WriteFile(partPath, v1[:len(v1)/2], 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := writePartialMeta(partPath+".yaml", partialMeta{URL: url, ETag: `"v1"`}); err != nil {
+				t.Fatal(err)
+			}
+
+			h := sha256.New()
+			got, err := k.downloadFile(context.Background(), url, t.TempDir(), nil, h)
+			if err != nil {
+				t.Fatalf("downloadFile() error = %v", err)
+			}
+
+			if want := "bytes=" + strconv.Itoa(len(v1)/2) + "-"; gotRange != want || gotIfRange != `"v1"` {
+				t.Errorf("Range = %q, If-Range = %q, want %q, %q", gotRange, gotIfRange, want, `"v1"`)
+			}
+			b, err := ioutil.ReadFile(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, tt.contents) {
+				t.Errorf("downloaded file = %q, want %q", b, tt.contents)
+			}
+			// The hash covers the whole file, including the bytes of the previous attempt when resumed
+			if sum := hex.EncodeToString(h.Sum(nil)); sum != sha256Hex(tt.contents) {
+				t.Errorf("hash = %s, want %s", sum, sha256Hex(tt.contents))
+			}
+			if _, err := os.Stat(partPath + ".yaml"); !os.IsNotExist(err) {
+				t.Errorf("partial download metadata exists after download, want none")
+			}
+		})
+	}
+}
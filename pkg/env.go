@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/borkod/kindly/config"
+)
+
+// Environment variables that record the directories added by the last env script,
+// so that they are replaced rather than added again when the script runs again
+const (
+	envPathVar    = "KINDLY_ENV_PATH"
+	envManPathVar = "KINDLY_ENV_MANPATH"
+)
+
+// envDirs are the kindly directories of a shell environment, in priority order
+type envDirs struct {
+	bin        []string
+	man        []string
+	completion []string
+}
+
+// Env returns a shell script for shell bash, zsh or fish that adds the bin directories to PATH,
+// the man pages directories to MANPATH, and loads the completions.
+// If projectDir is set, the project-local directories of the project take priority over the configured directories.
+func (k Kindly) Env(shell string, projectDir string) (string, error) {
+	if err := checkShell(shell); err != nil {
+		return "", err
+	}
+
+	cfgs := []config.Config{k.cfg}
+	if len(projectDir) > 0 {
+		cfgs = []config.Config{ProjectConfig(k.cfg, projectDir), k.cfg}
+	}
+
+	var d envDirs
+	for _, c := range cfgs {
+		d.bin = appendUnique(d.bin, c.OutBinDir)
+		d.man = appendUnique(d.man, c.OutManDir)
+		d.completion = appendUnique(d.completion, c.OutCompletionDir)
+	}
+
+	path := replaceDirs(os.Getenv("PATH"), os.Getenv(envPathVar), d.bin)
+	manPath := replaceDirs(os.Getenv("MANPATH"), os.Getenv(envManPathVar), d.man)
+	// An empty entry keeps the default man pages search path
+	if len(os.Getenv("MANPATH")) == 0 {
+		manPath += string(os.PathListSeparator)
+	}
+
+	var b strings.Builder
+	b.WriteString(setEnv(shell, "PATH", path))
+	b.WriteString(setEnv(shell, envPathVar, strings.Join(d.bin, string(os.PathListSeparator))))
+	b.WriteString(setEnv(shell, "MANPATH", manPath))
+	b.WriteString(setEnv(shell, envManPathVar, strings.Join(d.man, string(os.PathListSeparator))))
+
+	for i := len(d.completion) - 1; i >= 0; i-- {
+		c := shellQuote(shell, d.completion[i])
+		switch shell {
+		case "bash":
+			b.WriteString("for _kindly_f in " + c + "/*; do [ -r \"$_kindly_f\" ] && . \"$_kindly_f\"; done; unset _kindly_f\n")
+		case "zsh":
+			b.WriteString("typeset -U fpath\nfpath=(" + c + " $fpath)\n")
+		case "fish":
+			b.WriteString("contains " + c + " $fish_complete_path; or set -g fish_complete_path " + c + " $fish_complete_path\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+// EnvHook returns a shell script for shell bash, zsh or fish that runs the env script of kindly executable exe
+// whenever the current directory changes, so that project-local directories are used inside projects
+func EnvHook(shell string, exe string) (string, error) {
+	if err := checkShell(shell); err != nil {
+		return "", err
+	}
+
+	env := shellQuote(shell, exe) + " env --shell " + shell
+
+	switch shell {
+	case "bash":
+		return `_kindly_hook() {
+  if [ "$PWD" != "${_KINDLY_PWD:-}" ]; then
+    _KINDLY_PWD="$PWD"
+    eval "$(` + env + `)"
+  fi
+}
+case ";${PROMPT_COMMAND:-};" in
+  *";_kindly_hook;"*) ;;
+  *) PROMPT_COMMAND="_kindly_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`, nil
+	case "zsh":
+		return `_kindly_hook() {
+  eval "$(` + env + `)"
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _kindly_hook
+_kindly_hook
+`, nil
+	}
+
+	return `function _kindly_hook --on-variable PWD
+    ` + env + ` | source
+end
+_kindly_hook
+`, nil
+}
+
+func checkShell(shell string) error {
+	switch shell {
+	case "bash", "zsh", "fish":
+		return nil
+	}
+	return errors.New("Unsupported shell: " + shell)
+}
+
+// replaceDirs returns search path list without the directories in list old, with directories dirs in front
+func replaceDirs(list string, old string, dirs []string) string {
+	remove := make(map[string]bool)
+	for _, d := range append(filepath.SplitList(old), dirs...) {
+		remove[d] = true
+	}
+
+	p := append([]string{}, dirs...)
+	for _, d := range filepath.SplitList(list) {
+		if !remove[d] {
+			p = append(p, d)
+		}
+	}
+
+	return strings.Join(p, string(os.PathListSeparator))
+}
+
+// setEnv returns the statement of shell that exports environment variable n with value v
+func setEnv(shell string, n string, v string) string {
+	if shell == "fish" {
+		// fish keeps search paths as lists
+		if n == "PATH" || n == "MANPATH" {
+			q := make([]string, 0)
+			for _, d := range filepath.SplitList(v) {
+				q = append(q, shellQuote(shell, d))
+			}
+			return "set -gx " + n + " " + strings.Join(q, " ") + "\n"
+		}
+		return "set -gx " + n + " " + shellQuote(shell, v) + "\n"
+	}
+	return "export " + n + "=" + shellQuote(shell, v) + "\n"
+}
+
+// shellQuote returns s quoted for shell
+func shellQuote(shell string, s string) string {
+	if shell == "fish" {
+		return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func appendUnique(s []string, v string) []string {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
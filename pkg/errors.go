@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors returned when a package fails. They are wrapped with the name of the package,
+// or returned as one of the error types below. Use errors.Is to check for them.
+var (
+	// ErrPackageNotFound is returned when no spec source provides the package
+	ErrPackageNotFound = errors.New("Unavailable Package")
+	// ErrNotInstalled is returned when the package is not installed
+	ErrNotInstalled = errors.New("Package not installed")
+	// ErrChecksumMismatch is returned as a *ChecksumError when a downloaded file does not match its SHA value
+	ErrChecksumMismatch = errors.New("SHA MISMATCH")
+	// ErrArchUnavailable is returned as an *ArchError when the package has no asset for the OS architecture
+	ErrArchUnavailable = errors.New("Unavailable OS Architecture")
+	// ErrVersionUnavailable is returned as a *VersionError when no available version matches the requested version
+	ErrVersionUnavailable = errors.New("Unavailable version")
+	// ErrInvalidSpec is returned as a *SpecError when a spec cannot be parsed or cannot be used
+	ErrInvalidSpec = errors.New("Invalid spec")
+	// ErrVerification is returned when a signed index or a spec does not match the public key of its source
+	ErrVerification = errors.New("Verification failed")
+)
+
+// ChecksumError records a downloaded file that does not match its SHA value
+type ChecksumError struct {
+	URL       string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumError) Error() string {
+	if len(e.Expected) == 0 {
+		return ErrChecksumMismatch.Error() + ": " + e.URL + "\tNo " + e.Algorithm + " value for the OS architecture"
+	}
+	return ErrChecksumMismatch.Error() + ": " + e.URL + "\tExpected " + e.Algorithm + ": " + e.Expected + "\tActual: " + e.Actual
+}
+
+// Is reports whether target is ErrChecksumMismatch
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// ArchError records a package that has no asset for an OS architecture
+type ArchError struct {
+	Package string
+	OSArch  string
+}
+
+func (e *ArchError) Error() string {
+	return ErrArchUnavailable.Error() + ": " + e.Package + " " + e.OSArch
+}
+
+// Is reports whether target is ErrArchUnavailable
+func (e *ArchError) Is(target error) bool {
+	return target == ErrArchUnavailable
+}
+
+// VersionError records a requested version or version constraint that no available version of a package matches
+type VersionError struct {
+	Package   string
+	Version   string
+	Available []string
+}
+
+func (e *VersionError) Error() string {
+	return ErrVersionUnavailable.Error() + ": " + e.Package + "@" + e.Version + "\tAvailable versions: " + strings.Join(e.Available, ", ")
+}
+
+// Is reports whether target is ErrVersionUnavailable
+func (e *VersionError) Is(target error) bool {
+	return target == ErrVersionUnavailable
+}
+
+// SpecError records a spec that cannot be parsed or cannot be used
+type SpecError struct {
+	Spec string
+	Err  error
+}
+
+func (e *SpecError) Error() string {
+	return ErrInvalidSpec.Error() + " " + e.Spec + ": " + e.Err.Error()
+}
+
+// Is reports whether target is ErrInvalidSpec
+func (e *SpecError) Is(target error) bool {
+	return target == ErrInvalidSpec
+}
+
+// Unwrap returns the error of the spec
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is a list of errors of a command that processes many packages
+type MultiError []error
+
+func (m MultiError) Error() string {
+	s := make([]string, 0, len(m))
+	for _, err := range m {
+		s = append(s, err.Error())
+	}
+	return strings.Join(s, "\n")
+}
+
+// Is reports whether any of the errors matches target
+func (m MultiError) Is(target error) bool {
+	for _, err := range m {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches target, and if so, sets target to it
+func (m MultiError) As(target interface{}) bool {
+	for _, err := range m {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResultsError returns the errors of the failed packages of results r as a MultiError,
+// or nil if no package failed
+func ResultsError(r []PackageResult) error {
+	var m MultiError
+	for _, p := range r {
+		if p.Err != nil {
+			m = append(m, p.Err)
+		}
+	}
+
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
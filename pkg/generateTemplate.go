@@ -7,12 +7,13 @@ import (
 	"github.com/google/go-github/github"
 )
 
-// GenerateTemplate function implements template command
-func (k Kindly) GenerateTemplate(ctx context.Context, owner string, repo string) (kc KindlyStruct, err error) {
+// GenerateTemplate function implements template command.
+// If checksums is set, the assets of the latest release are downloaded and their SHA256 values embedded in the spec.
+func (k Kindly) GenerateTemplate(ctx context.Context, owner string, repo string, checksums bool) (kc KindlyStruct, err error) {
 	const goosList = "aix android darwin dragonfly freebsd hurd illumos ios js linux nacl netbsd openbsd plan9 solaris windows zos"
 	const goarchList = "386 amd64 amd64p32 arm armbe arm64 arm64be ppc64 ppc64le mips mipsle mips64 mips64le mips64p32 mips64p32le ppc riscv riscv64 s390 s390x sparc sparc64 wasm x86_64"
 
-	client := github.NewClient(nil)
+	client := github.NewClient(k.httpClient())
 	/*
 		repoInfo, _, err := client.Repositories.Get(ctx, owner, repo)
 		if err != nil {
@@ -77,6 +78,9 @@ func (k Kindly) GenerateTemplate(ctx context.Context, owner string, repo string)
 	kc.Spec.Version = release.GetName()
 	kc.Spec.Assets = make(map[string]Asset)
 
+	// Release download URLs of the assets before the version is templated
+	dlURLs := make(map[string]string)
+
 	for _, o := range strings.Split(goosList, " ") {
 		for _, a := range strings.Split(goarchList, " ") {
 			for _, n := range releaseInfo.Assets {
@@ -96,12 +100,26 @@ func (k Kindly) GenerateTemplate(ctx context.Context, owner string, repo string)
 						kc.Spec.Assets[goArch] = Asset{URL: kc.Spec.Assets[goArch].URL, ShaURL: url}
 					} else {
 						kc.Spec.Assets[goArch] = Asset{URL: url, ShaURL: kc.Spec.Assets[goArch].ShaURL}
+						dlURLs[goArch] = n.GetBrowserDownloadURL()
 					}
 				}
 			}
 		}
 	}
 
+	if checksums {
+		for goArch, a := range kc.Spec.Assets {
+			if _, ok := dlURLs[goArch]; !ok {
+				continue
+			}
+			k.logger.Debug("Calculating SHA256 value", "url", dlURLs[goArch])
+			if a.Sha256, err = hashURL(ctx, k.httpClient(), dlURLs[goArch]); err != nil {
+				return kc, err
+			}
+			kc.Spec.Assets[goArch] = a
+		}
+	}
+
 	return kc, nil
 
 }
@@ -9,60 +9,76 @@ import (
 )
 
 type dlInfo struct {
-	Name    string
-	Version string
-	Source  string
-	URL     string
-	URLSHA  string
-	osArch  string
+	Name       string
+	Version    string
+	Constraint string
+	Source     string
+	URL        string
+	URLSHA     string
+	Sha256     string
+	Sha512     string
+	osArch     string
+	asset      Asset
 }
 
-func (k Kindly) getValidYConfig(ctx context.Context, n string, f bool, u bool) (dlInfo, KindlyStruct, error) {
+func (k Kindly) getValidYConfig(ctx context.Context, req InstallRequest) (dlInfo, KindlyStruct, error) {
 	var err error
 	var yc KindlyStruct
+	n := req.Ref
 
 	// Pull out package version if provided
 	nVer := strings.SplitN(n, "@", 2)
 
-	dl := dlInfo{nVer[0], "", "", "", "", ""}
+	dl := dlInfo{Name: nVer[0]}
 
-	if len(nVer) > 1 {
-		dl.Version = semver.Canonical(nVer[1])
+	var c constraint
+	if len(nVer) > 1 && isConstraint(nVer[1]) {
+		// Version constraints are resolved against the versions listed in the spec
+		if c, err = parseConstraint(nVer[1]); err != nil {
+			return dl, yc, err
+		}
+		dl.Constraint = strings.TrimSpace(nVer[1])
+	} else if len(nVer) > 1 {
+		dl.Version = canonicalVersion(nVer[1])
 		if !semver.IsValid(dl.Version) {
 			return dl, yc, errors.New("Invalid package version: " + n)
 		}
 	}
 
-	if f {
-		dl.Source = dl.Name
+	switch req.Source {
+	case SourceFile:
+		dl.Source = expandPath(dl.Name)
 		// Read package yaml spec and initialize KindlyStruct struct
 		if yc, err = getYamlFile(dl.Source); err != nil {
 			return dl, yc, err
 		}
-	} else {
-		sourceURL := k.cfg.Source + dl.Name + ".yaml"
-		if u {
-			sourceURL = dl.Name
-		}
-		dl.Source = sourceURL
+	case SourceURL:
+		dl.Source = dl.Name
 		// Download package yaml spec and initialize KindlyStruct struct
-		if yc, err = getYamlURL(ctx, sourceURL); err != nil {
+		if yc, err = getYamlURL(ctx, k.httpClient(), dl.Source); err != nil {
+			return dl, yc, err
+		}
+	default:
+		// Find package yaml spec in the configured spec sources
+		src, b, err := k.findSpec(ctx, dl.Name)
+		if err != nil {
+			return dl, yc, err
+		}
+		dl.Source = src.Name
+		if yc, err = parseSpec(dl.Name, b); err != nil {
 			return dl, yc, err
 		}
-	}
-
-	// Check if package is available
-	if !(len(yc.Spec.Name) > 0) {
-		return dl, yc, errors.New("Unavailable Package: " + dl.Name)
 	}
 
 	dl.Name = yc.Spec.Name
 
-	// Check if requested version is higher value than the available version in the package
-	if len(dl.Version) > 0 {
-		if semver.Compare(dl.Version, yc.Spec.Version) == 1 {
-			return dl, yc, errors.New("Version requested: " + n + "\tLatest version: " + dl.Name + "@" + yc.Spec.Version)
+	// Resolve the highest available version that satisfies the constraint
+	if len(dl.Constraint) > 0 {
+		v, ok := c.latest(yc.AvailableVersions())
+		if !ok {
+			return dl, yc, &VersionError{Package: dl.Name, Version: dl.Constraint, Available: yc.AvailableVersions()}
 		}
+		dl.Version = v
 	}
 
 	// If version was not provided in the argument, set it to version in spec file
@@ -73,10 +89,51 @@ func (k Kindly) getValidYConfig(ctx context.Context, n string, f bool, u bool) (
 	// processFile Downloads file from url, checks SHA value, and saves it to tmpDir
 	dl.osArch = k.cfg.OS + "_" + k.cfg.Arch
 
-	// Check if OS architecture is available
-	if _, ok := yc.Spec.Assets[dl.osArch]; !ok {
-		return dl, yc, errors.New("Unavailable OS Architecture: " + dl.osArch)
+	// Resolve the asset of the requested version
+	if dl.asset, err = resolveAsset(yc, dl.Version, dl.osArch); err != nil {
+		return dl, yc, err
 	}
 
+	dl.Sha256 = dl.asset.Sha256
+	dl.Sha512 = dl.asset.Sha512
+
 	return dl, yc, nil
 }
+
+// resolveAsset returns the asset of version ver of the package for OS architecture osArch.
+// Only the spec version and the versions listed in the spec are available.
+// The checksums of the spec assets are pinned to the spec version, so a listed version must override
+// the asset with its own checksum, or the asset must have a sha_url file to verify it with.
+func resolveAsset(yc KindlyStruct, ver string, osArch string) (Asset, error) {
+	a, ok := yc.Spec.Assets[osArch]
+
+	if semver.Compare(ver, yc.Spec.Version) != 0 {
+		found := false
+		for _, sv := range yc.Spec.Versions {
+			if semver.Compare(sv.Version, ver) != 0 {
+				continue
+			}
+			found = true
+			// Per-version assets override the spec assets
+			if o, oOk := sv.Assets[osArch]; oOk {
+				a, ok = o, true
+			} else {
+				a.Sha256 = ""
+				a.Sha512 = ""
+			}
+		}
+		if !found {
+			return a, &VersionError{Package: yc.Spec.Name, Version: ver, Available: yc.AvailableVersions()}
+		}
+		if ok && len(a.Sha256) == 0 && len(a.Sha512) == 0 && len(a.ShaURL) == 0 {
+			return a, &SpecError{Spec: yc.Spec.Name, Err: errors.New("No checksum for version " + ver + " of OS architecture " + osArch)}
+		}
+	}
+
+	// Check if OS architecture is available
+	if !ok {
+		return a, &ArchError{Package: yc.Spec.Name, OSArch: osArch}
+	}
+
+	return a, nil
+}
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestResolveAsset(t *testing.T) {
+	spec := `spec:
+  name: foo
+  version: v2.0.0
+  assets:
+    linux_amd64:
+      url: https://example.com/foo_{{.Version}}_linux.tar.gz
+      sha256: linux
+    darwin_arm64:
+      url: https://example.com/foo_{{.Version}}_darwin.tar.gz
+      sha256: darwin
+      sha_url: https://example.com/foo_{{.Version}}_sums.txt
+  versions:
+  - version: v1.1.0
+    assets:
+      linux_amd64:
+        url: https://example.com/old/foo_{{.Version}}.tar.gz
+        sha256: old
+  - version: v1.0.0
+  bin: [foo]
+`
+
+	var yc KindlyStruct
+	if err := yaml.Unmarshal([]byte(spec), &yc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ver     string
+		osArch  string
+		want    Asset
+		wantErr error
+	}{
+		{"spec version", "v2.0.0", "linux_amd64", Asset{URL: "https://example.com/foo_{{.Version}}_linux.tar.gz", Sha256: "linux"}, nil},
+		{"listed version overrides asset", "v1.1.0", "linux_amd64", Asset{URL: "https://example.com/old/foo_{{.Version}}.tar.gz", Sha256: "old"}, nil},
+		{"listed version drops pinned checksum", "v1.0.0", "darwin_arm64", Asset{URL: "https://example.com/foo_{{.Version}}_darwin.tar.gz", ShaURL: "https://example.com/foo_{{.Version}}_sums.txt"}, nil},
+		{"listed version without checksum", "v1.0.0", "linux_amd64", Asset{}, ErrInvalidSpec},
+		{"unlisted version", "v1.2.0", "linux_amd64", Asset{}, ErrVersionUnavailable},
+		{"unavailable OS architecture", "v2.0.0", "windows_amd64", Asset{}, ErrArchUnavailable},
+		{"listed version of unavailable OS architecture", "v1.1.0", "darwin_amd64", Asset{}, ErrArchUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAsset(yc, tt.ver, tt.osArch)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("resolveAsset() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveAsset() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveAsset() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,83 +23,122 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 // GetYaml downloads the yaml and configures the KindlyStruct struct
-func getYamlURL(ctx context.Context, arg string) (KindlyStruct, error) {
-	const ConnectMaxWaitTime = 1 * time.Second
-	const RequestMaxWaitTime = 5 * time.Second
-
-	client := http.Client{
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout: ConnectMaxWaitTime,
-			}).DialContext,
-		},
+func getYamlURL(ctx context.Context, client *http.Client, arg string) (KindlyStruct, error) {
+	b, err := fetchURL(ctx, client, arg)
+	if errors.Is(err, errNotFound) {
+		return KindlyStruct{}, fmt.Errorf("%w: %s", ErrPackageNotFound, arg)
+	} else if err != nil {
+		return KindlyStruct{}, err
 	}
 
-	var yc KindlyStruct
-	buf := new(bytes.Buffer)
+	return parseSpec(arg, b)
+}
 
-	ctx, cancel := context.WithTimeout(ctx, RequestMaxWaitTime)
-	defer cancel()
+// fetchURL downloads the contents of a URL
+func fetchURL(ctx context.Context, client *http.Client, arg string) ([]byte, error) {
+	buf := new(bytes.Buffer)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, arg, nil)
 	if err != nil {
-		return yc, err
+		return nil, err
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return yc, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", errNotFound, arg)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(arg, resp)
+	}
+
 	if _, err = buf.ReadFrom(resp.Body); err != nil {
-		//fmt.Printf("Error downloading file: %s\n", arg)
-		return yc, err
+		return nil, err
 	}
 
-	err = yaml.Unmarshal(buf.Bytes(), &yc)
+	return buf.Bytes(), nil
+}
 
+// hashURL downloads the contents of a URL and returns its SHA256 value
+func hashURL(ctx context.Context, client *http.Client, arg string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, arg, nil)
 	if err != nil {
-		//fmt.Printf("Error parsing YAML file: %s\n", arg)
-		return yc, err
+		return "", err
 	}
 
-	return yc, nil
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(arg, resp)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// GetYaml downloads the yaml and configures the KindlyStruct struct
-func getYamlFile(arg string) (KindlyStruct, error) {
+// statusError returns the error of an unexpected HTTP response status for url arg.
+// It is a *url.Error, like the errors of the HTTP client.
+func statusError(arg string, resp *http.Response) error {
+	return &url.Error{Op: "Get", URL: arg, Err: errors.New(resp.Status)}
+}
 
+// parseSpec parses YAML spec n and configures the KindlyStruct struct
+func parseSpec(n string, b []byte) (KindlyStruct, error) {
 	var yc KindlyStruct
 
-	yamlFile, err := ioutil.ReadFile(expandPath(arg))
-	if err != nil {
-		return yc, err
+	if err := yaml.Unmarshal(b, &yc); err != nil {
+		return yc, &SpecError{Spec: n, Err: err}
 	}
-	err = yaml.Unmarshal(yamlFile, &yc)
-	if err != nil {
-		return yc, err
+
+	if !(len(yc.Spec.Name) > 0) {
+		return yc, &SpecError{Spec: n, Err: errors.New("Missing package name")}
 	}
 
 	return yc, nil
 }
 
+// GetYaml downloads the yaml and configures the KindlyStruct struct
+func getYamlFile(arg string) (KindlyStruct, error) {
+
+	yamlFile, err := ioutil.ReadFile(expandPath(arg))
+	if os.IsNotExist(err) {
+		return KindlyStruct{}, fmt.Errorf("%w: %s", ErrPackageNotFound, arg)
+	} else if err != nil {
+		return KindlyStruct{}, err
+	}
+
+	return parseSpec(arg, yamlFile)
+}
+
 // decompress decompresses a file
 func decompress(dst string, path string) error {
 
@@ -180,61 +219,8 @@ func decompress(dst string, path string) error {
 	}
 }
 
-// copyFile copies file to dst from src
-func copyFile(dst string, src string, binName string) (bool, error) {
-
-	//if cfg.Verbose {
-	//	k.logger.Println("Copying file:\t\t", binName)
-	//}
-	cbBool := false
-
-	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if info.Name() == binName && !info.IsDir() {
-
-			sourceFile, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer sourceFile.Close()
-
-			// Create the output directory
-			//if cfg.UniqueDir {
-			//	dirPath := filepath.Join(dst, info.Name())
-			//	if f, err := os.Stat(dirPath); os.IsNotExist(err) || !f.IsDir() {
-			//		os.Mkdir(dirPath, os.ModePerm)
-			//	}
-			//	dst = dirPath
-			//}
-
-			if _, err := os.Stat(dst); os.IsNotExist(err) {
-				if err2 := os.MkdirAll(dst, os.ModePerm); err2 != nil {
-					return err2
-				}
-			} else if err != nil {
-				return err
-			}
-
-			newFile, err := os.Create(filepath.Join(dst, info.Name()))
-			if err != nil {
-				return err
-			}
-			defer newFile.Close()
-
-			_, err = io.Copy(newFile, sourceFile)
-			if err != nil {
-				return err
-			}
-			cbBool = true
-		}
-
-		return nil
-	})
-	if err != nil {
-		return cbBool, err
-	}
-	return cbBool, nil
-}
-
+// writeManifest writes package manifest src into directory dst.
+// The manifest is written to a temporary file first and renamed into place.
 func writeManifest(src pkgManifest, dst string) error {
 
 	filename := filepath.Join(dst, src.Name+".yaml")
@@ -252,11 +238,28 @@ func writeManifest(src pkgManifest, dst string) error {
 		return err
 	}
 
-	if err = ioutil.WriteFile(filename, file, 0644); err != nil {
+	tmp, err := ioutil.TempFile(dst, "."+src.Name+".yaml.kindly_")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(file); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
 		return err
 	}
 
-	return nil
+	return os.Rename(tmp.Name(), filename)
 }
 
 // Unzip will decompress a zip archive, moving all files and folders within the zip file (parameter 1) to an output directory (parameter 2)
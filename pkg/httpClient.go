@@ -0,0 +1,200 @@
+package pkg
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/borkod/kindly/config"
+)
+
+// maxBackoff caps the wait time between retries
+const maxBackoff = 2 * time.Minute
+
+// newHTTPClient returns the http client shared by all network calls.
+// A zero timeout means no timeout.
+// Requests are authenticated with the configured credentials.
+// If the proxy, TLS or credential settings are invalid, every request made with the client fails with the error.
+func newHTTPClient(c config.Config) *http.Client {
+	var rt http.RoundTripper
+
+	t, err := newTransport(c)
+	if err != nil {
+		rt = errTransport{err}
+	} else if creds, err := loadCredentials(c); err != nil {
+		rt = errTransport{err}
+	} else {
+		rt = &retryTransport{next: &authTransport{next: t, creds: creds}, retries: c.Retries, backoff: c.RetryBackoff}
+	}
+
+	return &http.Client{
+		Transport: rt,
+		Timeout:   c.RequestTimeout,
+	}
+}
+
+// newTransport returns the transport for the proxy, timeout and TLS settings in c.
+// The proxy is read from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables unless c.Proxy is set.
+func newTransport(c config.Config) (*http.Transport, error) {
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: c.ConnectTimeout,
+		}).DialContext,
+		TLSHandshakeTimeout: c.ConnectTimeout,
+		IdleConnTimeout:     c.IdleTimeout,
+	}
+
+	if len(c.Proxy) > 0 {
+		u, err := url.Parse(c.Proxy)
+		if err != nil || len(u.Host) == 0 {
+			return nil, errors.New("Invalid proxy URL: " + c.Proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	if len(c.CABundle) == 0 && len(c.ClientCert) == 0 && len(c.ClientKey) == 0 {
+		return t, nil
+	}
+
+	t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+
+	// Trust the CA bundle in addition to the system certificates
+	if len(c.CABundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := ioutil.ReadFile(expandPath(c.CABundle))
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("No certificates found in CA bundle: " + c.CABundle)
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+
+	// Client certificate for servers that require mutual TLS
+	if len(c.ClientCert) > 0 || len(c.ClientKey) > 0 {
+		if len(c.ClientCert) == 0 || len(c.ClientKey) == 0 {
+			return nil, errors.New("Both ClientCert and ClientKey must be set")
+		}
+		cert, err := tls.LoadX509KeyPair(expandPath(c.ClientCert), expandPath(c.ClientKey))
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return t, nil
+}
+
+// httpClient returns the http client shared by all network calls
+func (k Kindly) httpClient() *http.Client {
+	if k.client != nil {
+		return k.client
+	}
+	return newHTTPClient(k.cfg)
+}
+
+// errTransport fails every request with err
+type errTransport struct {
+	err error
+}
+
+func (t errTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// retryTransport retries requests that fail with a 5xx or 429 status code or a connection reset.
+// The wait time between retries doubles after each attempt, unless the server sends a Retry-After header.
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait := t.backoff
+
+	for i := 0; ; i++ {
+		resp, err := t.next.RoundTrip(req)
+
+		// Only requests without a body can be sent again
+		if i >= t.retries || (req.Body != nil && req.Body != http.NoBody) || !isRetryable(resp, err) {
+			return resp, err
+		}
+
+		d := wait
+		if resp != nil {
+			if ra, ok := retryAfter(resp); ok {
+				d = ra
+			}
+			// Drain and close the failed response so the connection can be reused
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		if d > maxBackoff {
+			d = maxBackoff
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(d):
+		}
+
+		wait *= 2
+	}
+}
+
+// isRetryable reports whether a request that returned resp and err should be retried
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// isOffline reports whether request error err is caused by a server that can not be reached,
+// rather than by the response of the server
+func isOffline(err error) bool {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryAfter returns the wait time requested by the Retry-After header of resp
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	h := resp.Header.Get("Retry-After")
+	if len(h) == 0 {
+		return 0, false
+	}
+
+	if s, err := strconv.Atoi(h); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(h); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
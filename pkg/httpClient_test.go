@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingServer responds with status code status to the first fails requests and with 200 OK after,
+// and returns a function that reports the times of the requests
+func failingServer(t *testing.T, fails int, status int, header http.Header) (*httptest.Server, func() []time.Time) {
+	var mu sync.Mutex
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		n := len(times)
+		mu.Unlock()
+		if n <= fails {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), times...)
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	backoff := 20 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		fails        int
+		status       int
+		retries      int
+		wantStatus   int
+		wantRequests int
+	}{
+		{"succeeds after retries", 2, http.StatusServiceUnavailable, 3, http.StatusOK, 3},
+		{"too many requests", 1, http.StatusTooManyRequests, 3, http.StatusOK, 2},
+		{"gives up after retries", 5, http.StatusBadGateway, 2, http.StatusBadGateway, 3},
+		{"client error is not retried", 1, http.StatusNotFound, 3, http.StatusNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := failingServer(t, tt.fails, tt.status, nil)
+			c := &http.Client{Transport: &retryTransport{next: srv.Client().Transport, retries: tt.retries, backoff: backoff}}
+
+			resp, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			times := requests()
+			if len(times) != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", len(times), tt.wantRequests)
+			}
+
+			// The wait time doubles after each attempt
+			wait := backoff
+			for i := 1; i < len(times); i++ {
+				if d := times[i].Sub(times[i-1]); d < wait {
+					t.Errorf("wait before request %d = %v, want at least %v", i+1, d, wait)
+				}
+				wait *= 2
+			}
+		})
+	}
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) {
+	srv, requests := failingServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0"}})
+
+	// The Retry-After header replaces the backoff, which would outlast the request context
+	c := &http.Client{Transport: &retryTransport{next: srv.Client().Transport, retries: 1, backoff: time.Hour}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if n := len(requests()); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestRetryTransportCanceled(t *testing.T) {
+	srv, requests := failingServer(t, 5, http.StatusServiceUnavailable, nil)
+	c := &http.Client{Transport: &retryTransport{next: srv.Client().Transport, retries: 3, backoff: time.Hour}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(req); err == nil {
+		t.Fatal("Do() error = nil, want canceled request")
+	}
+	if n := len(requests()); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		wantOk bool
+	}{
+		{"seconds", "2", 2 * time.Second, true},
+		{"zero", "0", 0, true},
+		{"date in the past", "Mon, 02 Jan 2006 15:04:05 GMT", 0, true},
+		{"missing", "", 0, false},
+		{"negative", "-1", 0, false},
+		{"invalid", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if len(tt.header) > 0 {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+			got, ok := retryAfter(resp)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
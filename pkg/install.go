@@ -5,258 +5,399 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"errors"
-	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 )
 
+// fsMu serializes writes to the output and manifest directories between concurrent installs
+var fsMu sync.Mutex
+
+// RefSource is where the package spec of an InstallRequest is read from
+type RefSource string
+
+// Sources of the package spec of an InstallRequest
+const (
+	// SourceSpecs finds the spec in the configured spec sources. Ref is a package name, optionally as source/name.
+	SourceSpecs RefSource = ""
+	// SourceFile reads the spec from a local file. Ref is the path of the file.
+	SourceFile RefSource = "file"
+	// SourceURL downloads the spec from a URL. Ref is the URL.
+	SourceURL RefSource = "url"
+)
+
+// InstallRequest requests the install of the package Ref, read from Source.
+// Ref may end with @ and a version or version constraint, such as gh-cli@v1.0.0 or gh-cli@^1.4.
+type InstallRequest struct {
+	Ref    string
+	Source RefSource
+}
+
 // Install function implements install command
-func (k Kindly) Install(ctx context.Context, p string, f bool, u bool) (err error) {
+func (k Kindly) Install(ctx context.Context, req InstallRequest) (err error) {
+	_, err = k.install(ctx, req, nil)
+	return err
+}
 
-	if f && u {
-		return errors.New("Only one of 'file' or 'url' flags can be set.")
-	}
+// InstallAll installs packages concurrently, running at most jobs installs at a time.
+// Results are returned in the order of the requests.
+func (k Kindly) InstallAll(ctx context.Context, reqs []InstallRequest, jobs int) []PackageResult {
+	r := make([]PackageResult, len(reqs))
 
-	if u {
-		if !isValidUrl(p) {
-			return errors.New("Invalid URL.")
+	runJobs(len(reqs), jobs, func(i int) {
+		r[i] = PackageResult{Package: reqs[i].Ref, Status: StatusInstalled}
+		l, err := k.install(ctx, reqs[i], nil)
+		if err != nil {
+			r[i].Fail(err)
+			return
 		}
+		r[i].Package = l.Name
+		r[i].Version = l.Version
+		r[i].Files = k.files(l)
+	})
+
+	return r
+}
+
+// install installs the package of request req and returns its manifest.
+// If lock is set, the package must resolve to the locked version, URL and SHA256 value.
+func (k Kindly) install(ctx context.Context, req InstallRequest, lock *LockEntry) (l pkgManifest, err error) {
 
+	switch req.Source {
+	case SourceSpecs, SourceFile:
+	case SourceURL:
+		if !isValidUrl(req.Ref) {
+			return l, errors.New("Invalid URL.")
+		}
+	default:
+		return l, errors.New("Unknown spec source: " + string(req.Source))
 	}
 
 	// Create a temporary directory where files will be downloaded
 	tmpDir, err := ioutil.TempDir("", "kindly_")
 	if err != nil {
-		k.logger.Println(err)
-		os.Exit(1)
+		return l, err
 	}
 
 	// Clean up temporary directory
 	defer os.RemoveAll(tmpDir)
 
-	var tmpFile string
+	var tmpFile, sum string
 	var yc KindlyStruct
 	var dl dlInfo
 
-	if dl, yc, err = k.getValidYConfig(ctx, p, f, u); err != nil {
-		return err
+	if dl, yc, err = k.getValidYConfig(ctx, req); err != nil {
+		return l, err
 	}
 
 	// Applies Version values to the URL template
-	if dl.URL, dl.URLSHA, err = executeURL(dl, yc); err != nil {
-		return err
+	if dl.URL, dl.URLSHA, err = executeURL(dl); err != nil {
+		return l, err
+	}
+
+	// A locked package is verified against the SHA256 value in the lockfile
+	if lock != nil {
+		if dl.Version != lock.Version || dl.URL != lock.URL {
+			return l, errors.New("Lockfile mismatch: " + dl.Name + "@" + dl.Version + " " + dl.URL + "\tLocked: " + lock.Name + "@" + lock.Version + " " + lock.URL)
+		}
+		if len(dl.Sha256) > 0 && !strings.EqualFold(dl.Sha256, lock.Sha256) {
+			return l, &ChecksumError{URL: dl.URL, Algorithm: "sha256", Expected: lock.Sha256, Actual: dl.Sha256}
+		}
+		dl.Sha256 = lock.Sha256
 	}
 
 	// Downloads package file and package SHA file.
 	// Calculates package SHA value
 	// Compares package SHA value to SHA value in the SHA file
-	if tmpFile, err = k.processFile(ctx, dl, tmpDir); err != nil {
-		return err
+	k.report(ProgressEvent{Package: dl.Name, Version: dl.Version, Stage: ProgressResolved, URL: dl.URL})
+
+	if tmpFile, sum, err = k.processFile(ctx, dl, tmpDir); err != nil {
+		return l, err
 	}
 
+	k.report(ProgressEvent{Package: dl.Name, Version: dl.Version, Stage: ProgressDownloaded, URL: dl.URL})
+
 	// decompress tmpFile into tmpDir
 	if strings.Contains(tmpFile, "tar.gz") {
 		if err = decompress(tmpDir, tmpFile); err != nil {
-			return err
+			return l, err
 		}
 	}
 
 	if strings.Contains(tmpFile, "zip") {
 		if _, err = unzip(tmpFile, tmpDir); err != nil {
-			return err
+			return l, err
 		}
 	}
 
-	var l pkgManifest
 	l.Name = dl.Name
-	l.Date = time.Now().Format("2006-01-02 15:04:05")
+	l.Date = time.Now().Format(dateFormat)
 	l.Version = dl.Version
+	l.Constraint = dl.Constraint
 	l.Source = dl.Source
+	l.URL = dl.URL
+	l.Sha256 = sum
+
+	// Stage the package version directory; nothing is installed if any file fails
+	var t installTxn
+
+	verDir, err := t.stageDir(filepath.Join(k.cfg.PkgDir, l.Name), l.Version)
+	if err != nil {
+		return l, err
+	}
 
-	// Copy all extracted bin files from tmpDir into OutBinDir
+	// Copy all extracted bin files from tmpDir into the bin directory of the version
 	for _, n := range yc.Spec.Bin {
 		if strings.Contains(strings.ReplaceAll(n, " ", ""), "{{.OS}}") ||
 			strings.Contains(strings.ReplaceAll(n, " ", ""), "{{.Arch}}") {
 			if n, err = executeBin(n, k.cfg.OS, k.cfg.Arch); err != nil {
-				k.logger.Println("ERROR")
-				k.logger.Println(err)
-				continue
+				t.rollback()
+				return l, err
 			}
 		}
 		if k.cfg.OS == "windows" {
 			n = n + ".exe"
 		}
-		cpBool := false
-		if cpBool, err = copyFile(k.cfg.OutBinDir, tmpDir, n); err != nil {
-			k.logger.Println("ERROR")
-			k.logger.Println(err)
+		src, err := findFile(tmpDir, n)
+		if err != nil {
+			t.rollback()
+			return l, err
+		}
+		if len(src) == 0 {
+			t.rollback()
+			return l, errors.New("Binary file not found in package: " + n)
 		}
-		if cpBool {
-			l.Bin = append(l.Bin, n)
+		if err = k.stageFile(filepath.Join(verDir, binDir, n), src, 0755); err != nil {
+			t.rollback()
+			return l, err
 		}
+		l.Bin = append(l.Bin, n)
 	}
 
-	// Copy all extracted completion files from tmpDir into OutCompletionDir
+	// Copy all extracted completion files from tmpDir into the completion directory of the version
 	for _, n := range yc.Spec.Completion[k.cfg.Completion] {
-		cpBool := false
-		if cpBool, err = copyFile(k.cfg.OutCompletionDir, tmpDir, n); err != nil {
-			k.logger.Println("ERROR")
-			k.logger.Println(err)
+		src, err := findFile(tmpDir, n)
+		if err != nil {
+			t.rollback()
+			return l, err
 		}
-		if cpBool {
-			l.Completion = append(l.Completion, n)
+		if len(src) == 0 {
+			continue
 		}
+		if err = k.stageFile(filepath.Join(verDir, completionDir, n), src, 0644); err != nil {
+			t.rollback()
+			return l, err
+		}
+		l.Completion = append(l.Completion, n)
 	}
 
-	// Copy all extracted man pages files from tmpDir into OutManDir
+	// Copy all extracted man pages files from tmpDir into the man<section> directories of the version
 	for _, n := range yc.Spec.Man {
-		cpBool := false
-		if cpBool, err = copyFile(k.cfg.OutManDir, tmpDir, n); err != nil {
-			k.logger.Println("ERROR")
-			k.logger.Println(err)
+		src, err := findFile(tmpDir, n)
+		if err != nil {
+			t.rollback()
+			return l, err
+		}
+		if len(src) == 0 {
+			continue
 		}
-		if cpBool {
-			l.Man = append(l.Man, n)
+		m := manPage(n)
+		if err = k.stageFile(filepath.Join(verDir, manDir, m), src, 0644); err != nil {
+			t.rollback()
+			return l, err
 		}
+		l.Man = append(l.Man, m)
 	}
 
-	// Write the package manifest file
-	if err = writeManifest(l, k.cfg.ManifestDir); err != nil {
-		k.logger.Println(("ERROR"))
-		k.logger.Println(err)
+	// Keep the manifest of the version with its files, so that it can be activated later
+	if err = writeManifest(l, verDir); err != nil {
+		t.rollback()
+		return l, err
 	}
 
-	return nil
-}
-
-// Downloads package file and package SHA file.
-// Calculates package SHA value
-// Compares package SHA value to SHA value in the SHA file
-func (k Kindly) processFile(ctx context.Context, dl dlInfo, tmpDir string) (string, error) {
+	// Link the files of the version into the output directories
+	if err = k.stageLinks(&t, l, verDir); err != nil {
+		t.rollback()
+		return l, err
+	}
 
-	// Get the data
-	if k.cfg.Verbose {
-		k.logger.Println("Downloading file: ", dl.URL)
+	// Rename the version directory and links into place and write the package manifest last
+	if err = k.activate(&t, &l); err != nil {
+		return l, err
 	}
 
-	const ConnectMaxWaitTime = 1 * time.Second
-	const RequestMaxWaitTime = 5 * time.Second
+	k.report(ProgressEvent{Package: l.Name, Version: l.Version, Stage: ProgressInstalled, URL: l.URL})
 
-	client := http.Client{
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout: ConnectMaxWaitTime,
-			}).DialContext,
-		},
-	}
+	return l, nil
+}
 
-	ctx, cancel := context.WithTimeout(ctx, RequestMaxWaitTime)
-	defer cancel()
+// stageFile copies file src to dst within a staged version directory
+func (k Kindly) stageFile(dst string, src string, mode os.FileMode) error {
+	k.logger.Debug("Staging file", "path", dst)
+	return copyVerified(dst, src, mode)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dl.URL, nil)
-	if err != nil {
-		return "", err
+// removeStale deletes the files listed in manifest old that are not listed in manifest l
+func (k Kindly) removeStale(old pkgManifest, l pkgManifest) {
+	stale := func(dir string, oldFiles []string, files []string) {
+		for _, o := range oldFiles {
+			keep := false
+			for _, n := range files {
+				keep = keep || o == n
+			}
+			if keep {
+				continue
+			}
+			k.logger.Debug("Deleting file", "path", filepath.Join(dir, o))
+			if err := os.Remove(filepath.Join(dir, o)); err != nil && !os.IsNotExist(err) {
+				k.logger.Warn("Unable to delete file", "package", l.Name, "path", filepath.Join(dir, o), "error", err)
+			}
+		}
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+	stale(k.cfg.OutBinDir, old.Bin, l.Bin)
+	stale(k.cfg.OutCompletionDir, old.Completion, l.Completion)
+	stale(k.cfg.OutManDir, old.Man, l.Man)
+}
 
-	// DO I REALLY NEED TWO COPIES!?
-	var buf1, buf2 bytes.Buffer
-	w := io.MultiWriter(&buf1, &buf2)
+// Downloads package file and package SHA file.
+// Reuses the package file from the download cache if available
+// Streams the package file into a temporary file while calculating its SHA value
+// Compares package SHA value to SHA value in the SHA file
+// Moves the verified file into the download cache and links it into tmpDir
+// Returns the path and the SHA256 value of the file
+func (k Kindly) processFile(ctx context.Context, dl dlInfo, tmpDir string) (string, string, error) {
 
-	if _, err := io.Copy(w, resp.Body); err != nil {
-		return "", err
+	urlPath := strings.Split(dl.URL, "/")
+	outPath := filepath.Join(tmpDir, urlPath[len(urlPath)-1])
+
+	// Installs of the same asset wait for each other and reuse the cached file
+	defer lockURL(dl.URL)()
+
+	// Get the expected SHA value; inline checksums pinned in the spec are preferred to the sha file
+	expected := dl.Sha256
+	if len(dl.Sha256) == 0 && len(dl.Sha512) == 0 && len(dl.URLSHA) > 1 {
+		var err error
+		if expected, err = k.getShaFile(ctx, dl.URLSHA); err != nil {
+			// While offline, reuse the file cached for the same URL, which was verified when it was downloaded
+			e, ok := k.cacheLookup("", dl.URL)
+			if !ok || !isOffline(err) {
+				return "", "", err
+			}
+			k.logger.Warn("Unable to download SHA file, using cached file", "package", dl.Name, "url", dl.URLSHA, "error", err)
+			return outPath, e.Sha256, k.linkCached(e, outPath)
+		}
+		// A sha file without a value for the OS architecture verifies nothing
+		if len(expected) == 0 {
+			return "", "", &ChecksumError{URL: dl.URLSHA, Algorithm: "sha256"}
+		}
 	}
 
-	if k.cfg.Verbose {
-		k.logger.Println("Download finished.")
+	// Reuse the file from the download cache.
+	// The file cached for the same URL is used only if the spec has no SHA source to verify it with.
+	if len(dl.Sha512) == 0 {
+		if e, ok := k.cacheLookup(expected, dl.URL); ok {
+			k.logger.Debug("Using cached file", "package", dl.Name, "path", k.blobPath(e.Sha256))
+			return outPath, e.Sha256, k.linkCached(e, outPath)
+		}
 	}
 
-	// Calculate SHA256 of downloaded file
+	// Get the data
+	k.logger.Debug("Downloading file", "package", dl.Name, "url", dl.URL)
+
+	// Download the file, calculating SHA256 and SHA512 of downloaded file on the way
 	hash := sha256.New()
-	if _, err := io.Copy(hash, &buf1); err != nil {
-		return "", err
+	hash512 := sha512.New()
+
+	partPath, err := k.downloadFile(ctx, dl.URL, tmpDir, k.downloadProgress(dl), hash, hash512)
+	if err != nil {
+		return "", "", err
 	}
+
+	k.logger.Debug("Download finished", "package", dl.Name, "url", dl.URL)
+
 	sum := hex.EncodeToString(hash.Sum(nil))
+	sum512 := hex.EncodeToString(hash512.Sum(nil))
 
-	if k.cfg.Verbose {
-		k.logger.Println("Calculated SHA256 value: ", sum)
-	}
+	k.logger.Debug("Calculated SHA256 value", "package", dl.Name, "sha256", sum)
 
-	// Get the sha file
-	if len(dl.URLSHA) > 1 {
-		if k.cfg.Verbose {
-			k.logger.Println("Downloading SHA256 file: ", dl.URLSHA)
-		}
+	// Check if SHA values match
+	if len(expected) > 0 && !strings.EqualFold(expected, sum) {
+		k.removePartial(dl.URL)
+		return "", "", &ChecksumError{URL: dl.URL, Algorithm: "sha256", Expected: expected, Actual: sum}
+	}
+	if len(dl.Sha512) > 0 && !strings.EqualFold(dl.Sha512, sum512) {
+		k.removePartial(dl.URL)
+		return "", "", &ChecksumError{URL: dl.URL, Algorithm: "sha512", Expected: dl.Sha512, Actual: sum512}
+	}
+	if len(expected) == 0 && len(dl.Sha512) == 0 {
+		k.logger.Warn("No SHA value provided, skipping SHA value check", "package", dl.Name, "url", dl.URL)
+	}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, dl.URLSHA, nil)
-		if err != nil {
-			return "", err
-		}
+	// Move the verified file into the download cache
+	if partPath, err = k.cacheStore(partPath, sum, dl.URL); err != nil {
+		return "", "", err
+	}
 
-		respSha, err := client.Do(req)
-		if err != nil {
-			return "", err
-		}
-		defer respSha.Body.Close()
-
-		//buf := new(bytes.Buffer)
-		newStr := ""
-		//buf.ReadFrom(respSha.Body)
-		scanner := bufio.NewScanner(respSha.Body)
-		for scanner.Scan() {
-			shaLine := strings.SplitN(scanner.Text(), " ", 2)
-			if len(shaLine) > 1 {
-				if strings.Contains(shaLine[1], k.cfg.OS) && strings.Contains(shaLine[1], k.cfg.Arch) {
-					newStr = shaLine[0]
-				}
-			} else {
-				newStr = shaLine[0]
-			}
-		}
+	k.logger.Debug("Writing output file", "package", dl.Name, "path", outPath)
 
-		// Get the sha file
-		if k.cfg.Verbose {
-			k.logger.Println("SHA256 file hash value: ", newStr)
-		}
+	if len(k.cfg.CacheDir) == 0 {
+		return outPath, sum, moveFile(outPath, partPath)
+	}
+	return outPath, sum, linkFile(outPath, partPath)
+}
 
-		// Check if SHA256 values match
-		if newStr != sum {
-			return "", errors.New("SHA MISMATCH")
-		}
-	} else if k.cfg.Verbose {
-		k.logger.Println("NO SHA FILE PROVIDED. SKIPPING SHA VALUE CHECK")
+// linkCached records cache entry e as used and links its file to dst
+func (k Kindly) linkCached(e CacheEntry, dst string) error {
+	if err := k.touchCacheEntry(e); err != nil {
+		return err
 	}
+	return linkFile(dst, k.blobPath(e.Sha256))
+}
 
-	// Create the output file in temporary
-	urlPath := strings.Split(dl.URL, "/")
-	filepath := filepath.Join(tmpDir, urlPath[len(urlPath)-1])
+// getShaFile downloads the SHA file and returns the SHA256 value for the configured OS and architecture
+func (k Kindly) getShaFile(ctx context.Context, urlSha string) (string, error) {
+	k.logger.Debug("Downloading SHA256 file", "url", urlSha)
 
-	if k.cfg.Verbose {
-		k.logger.Println("Writing output file: ", filepath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlSha, nil)
+	if err != nil {
+		return "", err
 	}
 
-	out, err := os.Create(filepath)
+	respSha, err := k.httpClient().Do(req)
 	if err != nil {
 		return "", err
 	}
-	defer out.Close()
+	defer respSha.Body.Close()
+
+	if respSha.StatusCode != http.StatusOK {
+		return "", statusError(urlSha, respSha)
+	}
 
-	// Write the body to file
-	_, err = io.Copy(out, &buf2)
-	return filepath, err
+	newStr := ""
+	scanner := bufio.NewScanner(respSha.Body)
+	for scanner.Scan() {
+		shaLine := strings.SplitN(scanner.Text(), " ", 2)
+		if len(shaLine) > 1 {
+			if strings.Contains(shaLine[1], k.cfg.OS) && strings.Contains(shaLine[1], k.cfg.Arch) {
+				newStr = shaLine[0]
+			}
+		} else {
+			newStr = shaLine[0]
+		}
+	}
+
+	k.logger.Debug("SHA256 file hash value", "sha256", newStr)
+
+	return newStr, nil
 }
 
 // Applies OS and Architecture values to the binary file names template
@@ -287,14 +428,14 @@ func executeBin(n string, os string, arch string) (string, error) {
 }
 
 // Applies Version values to the URL template
-func executeURL(dl dlInfo, yc KindlyStruct) (string, string, error) {
-	urlT, err := template.New("url").Parse(yc.Spec.Assets[dl.osArch].URL)
+func executeURL(dl dlInfo) (string, string, error) {
+	urlT, err := template.New("url").Parse(dl.asset.URL)
 
 	if err != nil {
 		return "", "", err
 	}
 
-	urlShaT, err := template.New("urlSha").Parse(yc.Spec.Assets[dl.osArch].ShaURL)
+	urlShaT, err := template.New("urlSha").Parse(dl.asset.ShaURL)
 	if err != nil {
 		return "", "", err
 	}
@@ -314,3 +455,13 @@ func executeURL(dl dlInfo, yc KindlyStruct) (string, string, error) {
 	urlSha := buf.String()
 	return url, urlSha, nil
 }
+
+// manPage returns the path of man page file n relative to a man directory, such as man1/gh.1 for gh.1.gz,
+// so that man finds it in the directory of its section. A file without a section number stays at the top.
+func manPage(n string) string {
+	ext := filepath.Ext(strings.TrimSuffix(n, ".gz"))
+	if len(ext) < 2 || ext[1] < '1' || ext[1] > '9' {
+		return n
+	}
+	return filepath.Join("man"+ext[1:2], n)
+}
@@ -0,0 +1,184 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// stagedFile is a package directory or link created next to its destination, waiting to be renamed into place
+type stagedFile struct {
+	dst    string
+	tmp    string
+	backup string
+	placed bool
+}
+
+// installTxn installs the files of a package all or nothing.
+// Files are staged next to their destinations so that renaming them into place is atomic,
+// and the package manifest is written only after every file is in place.
+type installTxn struct {
+	files []*stagedFile
+}
+
+// stageDir creates a temporary directory that replaces directory name of dir on commit
+// and returns its path
+func (t *installTxn) stageDir(dir string, name string) (string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	tmp, err := ioutil.TempDir(dir, "."+name+".kindly_")
+	if err != nil {
+		return "", err
+	}
+	t.files = append(t.files, &stagedFile{dst: filepath.Join(dir, name), tmp: tmp})
+
+	return tmp, nil
+}
+
+// stageLink creates a temporary symbolic link to target that replaces file name of dir on commit.
+// If symbolic links are not supported, file src with the contents of target is linked or copied instead.
+func (t *installTxn) stageLink(dir string, name string, target string, src string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, "."+name+".kindly_")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	s := &stagedFile{dst: filepath.Join(dir, name), tmp: f.Name()}
+	t.files = append(t.files, s)
+
+	if err := os.Symlink(target, s.tmp); err != nil {
+		return linkFile(s.tmp, src)
+	}
+
+	return nil
+}
+
+// commit renames the staged files into place and then writes manifest l.
+// Files replaced by the install are restored if any step fails.
+func (t *installTxn) commit(l pkgManifest, manifestDir string) error {
+	for _, s := range t.files {
+		if _, err := os.Lstat(s.dst); err == nil {
+			s.backup = filepath.Join(filepath.Dir(s.dst), "."+filepath.Base(s.dst)+".kindly_old")
+			os.RemoveAll(s.backup)
+			if err := os.Rename(s.dst, s.backup); err != nil {
+				s.backup = ""
+				t.rollback()
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			t.rollback()
+			return err
+		}
+
+		if err := os.Rename(s.tmp, s.dst); err != nil {
+			t.rollback()
+			return err
+		}
+		s.placed = true
+	}
+
+	if err := writeManifest(l, manifestDir); err != nil {
+		t.rollback()
+		return err
+	}
+
+	// The install is complete; the replaced files are no longer needed
+	for _, s := range t.files {
+		if len(s.backup) > 0 {
+			os.RemoveAll(s.backup)
+		}
+	}
+
+	return nil
+}
+
+// rollback restores the files replaced by the install and deletes the staged files
+func (t *installTxn) rollback() {
+	for i := len(t.files) - 1; i >= 0; i-- {
+		s := t.files[i]
+		if s.placed {
+			os.RemoveAll(s.dst)
+			s.placed = false
+		} else {
+			os.RemoveAll(s.tmp)
+		}
+		if len(s.backup) > 0 {
+			os.Rename(s.backup, s.dst)
+			s.backup = ""
+		}
+	}
+}
+
+// copyVerified copies file src to dst with file mode mode and verifies the copy
+func copyVerified(dst string, src string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(dst, mode); err != nil {
+		return err
+	}
+
+	// Check that the copied file is intact
+	sum, err := hashFileHex(dst)
+	if err != nil {
+		return err
+	}
+	if sum != hex.EncodeToString(hash.Sum(nil)) {
+		return errors.New("Copied file is corrupt: " + dst)
+	}
+
+	return nil
+}
+
+// findFile returns the path of the first file named name in directory root
+func findFile(root string, name string) (string, error) {
+	found := ""
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 && info.Name() == name && !info.IsDir() {
+			found = path
+		}
+		return nil
+	})
+
+	return found, err
+}
@@ -0,0 +1,345 @@
+package pkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testArchive returns a tar.gz archive with file name and its contents
+func testArchive(t *testing.T, name string, contents string) []byte {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(contents)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+// testServer serves files, keyed by URL path, and returns a function that reports the number of requests of a path
+func testServer(t *testing.T, files map[string][]byte) (*httptest.Server, func(string) int) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		b, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[path]
+	}
+}
+
+// testKindly returns a client for linux_amd64 with all kindly directories in a new directory
+func testKindly(t *testing.T, srv *httptest.Server) *Kindly {
+	cfg := DefaultConfig()
+	cfg.OS = "linux"
+	cfg.Arch = "amd64"
+	return New(WithConfig(cfg), WithRoot(t.TempDir()), WithHTTPClient(srv.Client()))
+}
+
+// installSpec writes spec s into a new file and installs it
+func installSpec(k *Kindly, t *testing.T, s string) error {
+	filename := filepath.Join(t.TempDir(), "foo.yaml")
+	writeFile(t, filename, s)
+	return k.Install(context.Background(), InstallRequest{Ref: filename, Source: SourceFile})
+}
+
+func TestInstallChecksum(t *testing.T) {
+	archive := testArchive(t, "foo", "foo v1")
+	sum := sha256Hex(archive)
+
+	srv, _ := testServer(t, map[string][]byte{
+		"/foo_v1.0.0.tar.gz": archive,
+		"/sums.txt":          []byte(sum + "  foo_v1.0.0_linux_amd64.tar.gz\n"),
+		"/sums_darwin.txt":   []byte(sum + "  foo_v1.0.0_darwin_arm64.tar.gz\n"),
+	})
+
+	spec := `spec:
+  name: foo
+  version: v1.0.0
+  assets:
+    linux_amd64:
+      url: ` + srv.URL + `/foo_{{.Version}}.tar.gz
+%s
+  bin: [foo]
+`
+
+	tests := []struct {
+		name    string
+		asset   string
+		wantErr error
+	}{
+		{"sha256", "      sha256: " + sum, nil},
+		{"sha256 mismatch", "      sha256: " + strings.Repeat("0", 64), ErrChecksumMismatch},
+		{"sha512 mismatch", "      sha512: " + strings.Repeat("0", 128), ErrChecksumMismatch},
+		{"sha file", "      sha_url: " + srv.URL + "/sums.txt", nil},
+		{"sha file without OS architecture", "      sha_url: " + srv.URL + "/sums_darwin.txt", ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := testKindly(t, srv)
+
+			err := installSpec(k, t, strings.Replace(spec, "%s", tt.asset, 1))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Install() error = %v, want %v", err, tt.wantErr)
+				}
+				assertNotInstalled(t, k, "foo")
+				// A file that fails verification is not kept in the download cache
+				if _, err := os.Stat(k.blobPath(sum)); !os.IsNotExist(err) {
+					t.Errorf("cached file %s exists, want none", k.blobPath(sum))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			assertInstalled(t, k, "foo", "foo v1")
+		})
+	}
+}
+
+func TestInstallCachedFileVerified(t *testing.T) {
+	archive := testArchive(t, "foo", "foo v1")
+	srv, requests := testServer(t, map[string][]byte{"/foo_v1.0.0.tar.gz": archive})
+	k := testKindly(t, srv)
+
+	spec := `spec:
+  name: foo
+  version: v1.0.0
+  assets:
+    linux_amd64:
+      url: ` + srv.URL + `/foo_{{.Version}}.tar.gz
+      sha256: %s
+  bin: [foo]
+`
+
+	if err := installSpec(k, t, strings.Replace(spec, "%s", sha256Hex(archive), 1)); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	// A spec with another SHA value for the same URL does not use the cached file
+	err := installSpec(k, t, strings.Replace(spec, "%s", strings.Repeat("0", 64), 1))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Install() error = %v, want %v", err, ErrChecksumMismatch)
+	}
+	if n := requests("/foo_v1.0.0.tar.gz"); n != 2 {
+		t.Errorf("downloads = %d, want 2", n)
+	}
+}
+
+func TestInstallRollback(t *testing.T) {
+	srv, _ := testServer(t, map[string][]byte{
+		"/foo_v1.0.0.tar.gz": testArchive(t, "foo", "foo v1"),
+		"/foo_v2.0.0.tar.gz": testArchive(t, "foo", "foo v2"),
+	})
+	k := testKindly(t, srv)
+
+	spec := `spec:
+  name: foo
+  version: %s
+  assets:
+    linux_amd64:
+      url: ` + srv.URL + `/foo_{{.Version}}.tar.gz
+  bin: [%s]
+`
+
+	if err := installSpec(k, t, strings.NewReplacer("version: %s", "version: v1.0.0", "[%s]", "[foo]").Replace(spec)); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	// The second binary of the new version is missing, so nothing of it is installed
+	err := installSpec(k, t, strings.NewReplacer("version: %s", "version: v2.0.0", "[%s]", "[foo, bar]").Replace(spec))
+	if err == nil || err.Error() != "Binary file not found in package: bar" {
+		t.Fatalf("Install() error = %v, want missing binary", err)
+	}
+
+	assertInstalled(t, k, "foo", "foo v1")
+
+	l, err := readManifest(filepath.Join(k.cfg.ManifestDir, "foo.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Version != "v1.0.0" {
+		t.Errorf("manifest version = %s, want v1.0.0", l.Version)
+	}
+
+	// No staged version directory is left behind
+	files, err := ioutil.ReadDir(filepath.Join(k.cfg.PkgDir, "foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "v1.0.0" {
+		t.Errorf("package versions = %v, want only v1.0.0", names(files))
+	}
+}
+
+// assertInstalled checks that binary n of package n is installed with contents contents
+func assertInstalled(t *testing.T, k *Kindly, n string, contents string) {
+	t.Helper()
+
+	b, err := ioutil.ReadFile(filepath.Join(k.cfg.OutBinDir, n))
+	if err != nil {
+		t.Fatalf("binary %s: %v", n, err)
+	}
+	if string(b) != contents {
+		t.Errorf("binary %s = %q, want %q", n, b, contents)
+	}
+	if _, err := os.Stat(filepath.Join(k.cfg.ManifestDir, n+".yaml")); err != nil {
+		t.Errorf("manifest %s: %v", n, err)
+	}
+}
+
+// assertNotInstalled checks that no binary, manifest or version directory of package n exists
+func assertNotInstalled(t *testing.T, k *Kindly, n string) {
+	t.Helper()
+
+	for _, f := range []string{filepath.Join(k.cfg.OutBinDir, n), filepath.Join(k.cfg.ManifestDir, n+".yaml")} {
+		if _, err := os.Lstat(f); !os.IsNotExist(err) {
+			t.Errorf("%s exists, want none", f)
+		}
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(k.cfg.PkgDir, n))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if len(files) > 0 {
+		t.Errorf("package versions = %v, want none", names(files))
+	}
+}
+
+// names returns the names of files
+func names(files []os.FileInfo) []string {
+	n := make([]string, 0, len(files))
+	for _, f := range files {
+		n = append(n, f.Name())
+	}
+	return n
+}
+
+func TestInstallOfflineShaFile(t *testing.T) {
+	archive := testArchive(t, "foo", "foo v1")
+	files := map[string][]byte{
+		"/foo_v1.0.0.tar.gz": archive,
+		"/sums.txt":          []byte(sha256Hex(archive) + "  foo_v1.0.0_linux_amd64.tar.gz\n"),
+	}
+	srv, _ := testServer(t, files)
+	k := testKindly(t, srv)
+
+	spec := `spec:
+  name: foo
+  version: v1.0.0
+  assets:
+    linux_amd64:
+      url: ` + srv.URL + `/foo_{{.Version}}.tar.gz
+      sha_url: ` + srv.URL + `/sums.txt
+  bin: [foo]
+`
+
+	if err := installSpec(k, t, spec); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	// A sha file that the server no longer has is not offline; the cached file is not used
+	delete(files, "/sums.txt")
+	if err := installSpec(k, t, spec); err == nil {
+		t.Fatal("Install() error = nil, want error for missing sha file")
+	}
+
+	// Offline, the file cached for the same URL is used
+	srv.Close()
+	if err := installSpec(k, t, spec); err != nil {
+		t.Fatalf("Install() offline error = %v", err)
+	}
+	assertInstalled(t, k, "foo", "foo v1")
+}
+
+func TestManPage(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"gh.1", filepath.Join("man1", "gh.1")},
+		{"gh.1.gz", filepath.Join("man1", "gh.1.gz")},
+		{"foo-config.5", filepath.Join("man5", "foo-config.5")},
+		{"gh.3p", filepath.Join("man3", "gh.3p")},
+		{"gh.txt", "gh.txt"},
+		{"gh", "gh"},
+	}
+
+	for _, tt := range tests {
+		if got := manPage(tt.name); got != tt.want {
+			t.Errorf("manPage(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInstallManPage(t *testing.T) {
+	srv, _ := testServer(t, map[string][]byte{"/foo_v1.0.0.tar.gz": testArchive(t, "foo.1", "foo manual")})
+	k := testKindly(t, srv)
+
+	spec := `spec:
+  name: foo
+  version: v1.0.0
+  assets:
+    linux_amd64:
+      url: ` + srv.URL + `/foo_{{.Version}}.tar.gz
+  bin: [foo.1]
+  man: [foo.1]
+`
+
+	if err := installSpec(k, t, spec); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	// man looks for pages in the man<section> directories of a MANPATH directory
+	b, err := ioutil.ReadFile(filepath.Join(k.cfg.OutManDir, "man1", "foo.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "foo manual" {
+		t.Errorf("man page = %q, want %q", b, "foo manual")
+	}
+
+	if err := k.Remove(context.Background(), "foo"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(k.cfg.OutManDir, "man1", "foo.1")); !os.IsNotExist(err) {
+		t.Errorf("man page exists after Remove(), want none")
+	}
+}
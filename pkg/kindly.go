@@ -1,23 +1,135 @@
 package pkg
 
 import (
-	"log"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
 
 	"github.com/borkod/kindly/config"
 )
 
+// DefaultSource is the default location of package spec files
+const DefaultSource = "github://borkod/kindly-specs/specs@main"
+
 // Kindly struct stores kindly config
 type Kindly struct {
-	cfg    config.Config
-	logger *log.Logger
+	cfg      config.Config
+	logger   Logger
+	client   *http.Client
+	progress func(ProgressEvent)
+	indexes  *indexCache
+}
+
+// Option configures a Kindly client created with New
+type Option func(*Kindly)
+
+// New returns a Kindly client configured by options opts, which are applied in order.
+// Without options, the client uses DefaultConfig and does not log.
+func New(opts ...Option) *Kindly {
+	k := &Kindly{cfg: DefaultConfig(), logger: &logger{w: ioutil.Discard, level: LevelError, format: LogFormatText}, indexes: &indexCache{}}
+
+	for _, o := range opts {
+		o(k)
+	}
+
+	if k.client == nil {
+		k.client = newHTTPClient(k.cfg)
+	}
+
+	return k
+}
+
+// WithConfig replaces the configuration of the client
+func WithConfig(c config.Config) Option {
+	return func(k *Kindly) {
+		k.cfg = c
+	}
+}
+
+// WithHTTPClient sets the http client used for all network calls.
+// Otherwise the client is created from the proxy, timeout, TLS and credential settings of the configuration.
+func WithHTTPClient(c *http.Client) Option {
+	return func(k *Kindly) {
+		k.client = c
+	}
+}
+
+// WithLogger sets the logger of the client
+func WithLogger(l Logger) Option {
+	return func(k *Kindly) {
+		k.logger = l
+	}
+}
+
+// WithRoot sets the output, manifest, package versions and download cache directories
+// to directories in root directory dir
+func WithRoot(dir string) Option {
+	return func(k *Kindly) {
+		k.cfg = rootConfig(k.cfg, dir)
+	}
+}
+
+// WithSpecSource adds spec source s after the spec sources already configured.
+// If no sources are configured, s is added after the Source of the configuration.
+func WithSpecSource(s config.Source) Option {
+	return func(k *Kindly) {
+		k.cfg.Sources = append(Sources(k.cfg), s)
+	}
+}
+
+// WithProgress sets function fn to be called with the progress of installs.
+// fn is called from concurrent installs at the same time, and must not block.
+func WithProgress(fn func(ProgressEvent)) Option {
+	return func(k *Kindly) {
+		k.progress = fn
+	}
+}
+
+// DefaultConfig returns the default configuration, with kindly directories in $HOME/.kindly
+func DefaultConfig() config.Config {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	c := config.Config{
+		Netrc:          filepath.Join(home, ".netrc"),
+		Completion:     "bash",
+		Source:         DefaultSource,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		ConnectTimeout: 10 * time.Second,
+		RequestTimeout: 10 * time.Minute,
+		IdleTimeout:    90 * time.Second,
+		Retries:        3,
+		RetryBackoff:   1 * time.Second,
+	}
+
+	return rootConfig(c, filepath.Join(home, ".kindly"))
+}
+
+// rootConfig returns config c with all kindly directories in root directory dir
+func rootConfig(c config.Config, dir string) config.Config {
+	c.ManifestDir = filepath.Join(dir, "manifests")
+	c.OutBinDir = filepath.Join(dir, "bin")
+	c.OutCompletionDir = filepath.Join(dir, "completion")
+	c.OutManDir = filepath.Join(dir, "man")
+	c.CacheDir = filepath.Join(dir, "cache")
+	c.PkgDir = filepath.Join(dir, "pkgs")
+
+	return c
 }
 
 // SetConfig sets the kindly struct config
 func (k *Kindly) SetConfig(c config.Config) {
 	k.cfg = c
+	k.client = newHTTPClient(c)
 }
 
 // SetLogger sets the kindly struct logger
-func (k *Kindly) SetLogger(l *log.Logger) {
+func (k *Kindly) SetLogger(l Logger) {
 	k.logger = l
 }
@@ -0,0 +1,19 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/borkod/kindly/config"
+)
+
+func TestWithSpecSource(t *testing.T) {
+	internal := config.Source{Name: "internal", URL: "file:///internal"}
+
+	k := New(WithSpecSource(internal))
+
+	want := []config.Source{{Name: DefaultSourceName, URL: DefaultSource}, internal}
+	if got := k.sources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sources() = %+v, want %+v", got, want)
+	}
+}
@@ -3,15 +3,22 @@ package pkg
 import (
 	"context"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
-
-	"github.com/google/go-github/github"
-	"gopkg.in/yaml.v2"
 )
 
+// PackageInfo describes an available or installed package
+type PackageInfo struct {
+	Name       string `json:"name" yaml:"name"`
+	Version    string `json:"version" yaml:"version"`
+	Source     string `json:"source" yaml:"source"`
+	Constraint string `json:"constraint,omitempty" yaml:"constraint,omitempty"`
+	Held       bool   `json:"held,omitempty" yaml:"held,omitempty"`
+}
+
 // ListPackages function implements list command
-func (k Kindly) ListPackages(ctx context.Context, installed bool) (s []string, err error) {
+func (k Kindly) ListPackages(ctx context.Context, installed bool) (s []PackageInfo, err error) {
 
 	if installed {
 		s, err = k.listInstalled(ctx)
@@ -22,58 +29,60 @@ func (k Kindly) ListPackages(ctx context.Context, installed bool) (s []string, e
 	return s, err
 }
 
-func (k Kindly) listInstalled(ctx context.Context) (s []string, err error) {
+func (k Kindly) listInstalled(ctx context.Context) (s []PackageInfo, err error) {
+	// No packages are installed before the manifests directory is created
 	files, err := ioutil.ReadDir(k.cfg.ManifestDir)
-	if err != nil {
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
 		return s, err
 	}
 
-	// Should we do this or just use file names?
 	for _, file := range files {
-		l := new(pkgManifest)
-		// Read package manifest
-		file, err := ioutil.ReadFile(filepath.Join(k.cfg.ManifestDir, file.Name()))
-		if err != nil {
-			return s, err
+		if !strings.HasSuffix(file.Name(), ".yaml") {
+			continue
 		}
 
-		if err := yaml.Unmarshal(file, l); err != nil {
+		// Read package manifest
+		l, err := readManifest(filepath.Join(k.cfg.ManifestDir, file.Name()))
+		if err != nil {
 			return s, err
 		}
 
-		s = append(s, l.Name+"@"+l.Version)
+		s = append(s, PackageInfo{Name: l.Name, Version: l.Version, Source: l.Source, Constraint: l.Constraint, Held: l.Held})
 	}
 
-	/*
-		for _, file := range files {
-			s = append(s, strings.TrimSuffix(file.Name(), ".yaml"))
-		}
-	*/
-
 	return s, nil
 }
 
-func (k Kindly) listAvailable(ctx context.Context) (s []string, err error) {
-	client := github.NewClient(nil)
-	source := k.cfg.Source
-	source = strings.Replace(source, "https://raw.githubusercontent.com/", "", 1)
-	source = strings.Replace(source, "/main", "", 1)
-	source = strings.TrimSuffix(source, "/")
-	sInfo := strings.Split(source, "/")
+func (k Kindly) listAvailable(ctx context.Context) (s []PackageInfo, err error) {
+	seen := make(map[string]bool)
 
-	_, dir, _, err := client.Repositories.GetContents(ctx, sInfo[0], sInfo[1], sInfo[2], nil)
-	if err != nil {
-		return s, err
-	}
+	for _, c := range k.sources() {
+		src, err := NewSpecSource(c.URL, k.httpClient())
+		if err != nil {
+			return s, err
+		}
 
-	// Should we read the spec and get the name of the package from spec or use just file name?
-	for _, n := range dir {
-		_, yc, err := k.getValidYConfig(ctx, strings.TrimSuffix(*n.Name, ".yaml"), false, false)
+		names, err := src.List(ctx)
 		if err != nil {
 			return s, err
 		}
-		s = append(s, yc.Spec.Name+"@"+yc.Spec.Version)
-		//s = append(s, strings.TrimSuffix(*n.Name, ".yaml"))
+
+		// Should we read the spec and get the name of the package from spec or use just file name?
+		for _, n := range names {
+			// Packages are resolved from the highest priority source; skip the ones it shadows
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+
+			_, yc, err := k.getValidYConfig(ctx, InstallRequest{Ref: c.Name + "/" + n})
+			if err != nil {
+				return s, err
+			}
+			s = append(s, PackageInfo{Name: yc.Spec.Name, Version: yc.Spec.Version, Source: c.Name})
+		}
 	}
 
 	return s, nil
@@ -0,0 +1,117 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LockFileName is the default name of the lockfile
+const LockFileName = "kindly.lock"
+
+// LockFile lists installed packages with their exact version, asset URL and SHA256 value
+type LockFile struct {
+	Packages []LockEntry `json:"packages" yaml:"packages"`
+}
+
+// LockEntry is a package of the lockfile
+type LockEntry struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+	URL     string `json:"url" yaml:"url"`
+	Sha256  string `json:"sha256" yaml:"sha256"`
+	Source  string `json:"source" yaml:"source"`
+}
+
+// Lock returns the lockfile of the installed packages
+func (k Kindly) Lock(ctx context.Context) (lf LockFile, err error) {
+	// No packages are installed before the manifests directory is created
+	files, err := ioutil.ReadDir(k.cfg.ManifestDir)
+	if os.IsNotExist(err) {
+		return lf, nil
+	} else if err != nil {
+		return lf, err
+	}
+
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".yaml") {
+			continue
+		}
+		l, err := readManifest(filepath.Join(k.cfg.ManifestDir, f.Name()))
+		if err != nil {
+			return lf, err
+		}
+		// Packages installed by older versions of kindly did not record their asset
+		if len(l.URL) == 0 || len(l.Sha256) == 0 {
+			return lf, errors.New("Package has no recorded asset SHA256 value, install it again to lock it: " + l.Name)
+		}
+		lf.Packages = append(lf.Packages, LockEntry{Name: l.Name, Version: l.Version, URL: l.URL, Sha256: l.Sha256, Source: l.Source})
+	}
+
+	sort.Slice(lf.Packages, func(i, j int) bool {
+		return lf.Packages[i].Name < lf.Packages[j].Name
+	})
+
+	return lf, nil
+}
+
+// Sync installs the packages of lockfile lf at their locked versions, running at most jobs installs at a time.
+// If frozen is set, each package must resolve to the locked asset URL and SHA256 value.
+// Results are returned in the order of the lockfile.
+func (k Kindly) Sync(ctx context.Context, lf LockFile, frozen bool, jobs int) []PackageResult {
+	r := make([]PackageResult, len(lf.Packages))
+
+	runJobs(len(lf.Packages), jobs, func(i int) {
+		e := lf.Packages[i]
+		r[i] = PackageResult{Package: e.Name, Version: e.Version, Status: StatusUpToDate}
+
+		// Skip packages that are already installed as locked
+		l, err := readManifest(filepath.Join(k.cfg.ManifestDir, e.Name+".yaml"))
+		if err == nil && l.Version == e.Version && strings.EqualFold(l.Sha256, e.Sha256) {
+			return
+		}
+
+		req := k.manifestRef(&pkgManifest{Name: e.Name, Source: e.Source})
+		req.Ref = req.Ref + "@" + e.Version
+
+		var lock *LockEntry
+		if frozen {
+			lock = &e
+		}
+
+		nl, err := k.install(ctx, req, lock)
+		if err != nil {
+			r[i].Fail(err)
+			return
+		}
+		r[i].Status = StatusInstalled
+		r[i].Files = k.files(nl)
+	})
+
+	return r
+}
+
+// ReadLockFile reads lockfile filename
+func ReadLockFile(filename string) (lf LockFile, err error) {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return lf, err
+	}
+	err = yaml.Unmarshal(file, &lf)
+	return lf, err
+}
+
+// WriteLockFile writes lockfile lf to filename
+func WriteLockFile(filename string, lf LockFile) error {
+	file, err := yaml.Marshal(lf)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, file, 0644)
+}
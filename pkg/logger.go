@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger is a leveled structured logger.
+// Fields are key/value pairs, such as "package", "gh-cli", "url", "https://...".
+type Logger interface {
+	Debug(msg string, fields ...interface{})
+	Info(msg string, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+	Error(msg string, fields ...interface{})
+}
+
+// Level is the severity of a log message
+type Level int
+
+// Log levels, from the most to the least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "level(" + strconv.Itoa(int(l)) + ")"
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level named s: debug, info, warn or error
+func ParseLevel(s string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(s, n) {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, errors.New("Invalid log level: " + s)
+}
+
+// logger writes log messages of level and above to w in format text or json
+type logger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	level  Level
+	format string
+}
+
+// NewLogger returns a Logger that writes messages of level and above to w,
+// one per line, in format LogFormatText or LogFormatJSON
+func NewLogger(w io.Writer, level Level, format string) (Logger, error) {
+	if format != LogFormatText && format != LogFormatJSON {
+		return nil, errors.New("Invalid log format: " + format)
+	}
+	return &logger{w: w, level: level, format: format}, nil
+}
+
+func (l *logger) Debug(msg string, fields ...interface{}) { l.log(LevelDebug, msg, fields) }
+func (l *logger) Info(msg string, fields ...interface{})  { l.log(LevelInfo, msg, fields) }
+func (l *logger) Warn(msg string, fields ...interface{})  { l.log(LevelWarn, msg, fields) }
+func (l *logger) Error(msg string, fields ...interface{}) { l.log(LevelError, msg, fields) }
+
+func (l *logger) log(level Level, msg string, fields []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	// A key without a value gets an empty value
+	if len(fields)%2 != 0 {
+		fields = append(fields, "")
+	}
+
+	var b strings.Builder
+	now := time.Now()
+
+	if l.format == LogFormatJSON {
+		b.WriteString(`{"time":` + jsonValue(now.Format(time.RFC3339)))
+		b.WriteString(`,"level":` + jsonValue(level.String()))
+		b.WriteString(`,"msg":` + jsonValue(msg))
+		for i := 0; i < len(fields); i += 2 {
+			b.WriteString("," + jsonValue(fmt.Sprint(fields[i])) + ":" + jsonValue(fields[i+1]))
+		}
+		b.WriteString("}\n")
+	} else {
+		b.WriteString(now.Format("15:04:05") + " " + strings.ToUpper(level.String()) + " " + msg)
+		for i := 0; i < len(fields); i += 2 {
+			b.WriteString(" " + fmt.Sprint(fields[i]) + "=" + textValue(fields[i+1]))
+		}
+		b.WriteString("\n")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, b.String())
+}
+
+// jsonValue returns value v encoded as JSON. Errors are encoded as their message.
+func jsonValue(v interface{}) string {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return string(b)
+}
+
+// textValue returns value v formatted for the text format, quoted if it contains spaces
+func textValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if len(s) == 0 || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// OutdatedPackage compares the installed version of a package with the versions available in its spec
+type OutdatedPackage struct {
+	Name       string `json:"name" yaml:"name"`
+	Installed  string `json:"installed" yaml:"installed"`
+	Wanted     string `json:"wanted" yaml:"wanted"`
+	Latest     string `json:"latest" yaml:"latest"`
+	Constraint string `json:"constraint,omitempty" yaml:"constraint,omitempty"`
+	Held       bool   `json:"held,omitempty" yaml:"held,omitempty"`
+	Outdated   bool   `json:"outdated" yaml:"outdated"`
+	Error      string `json:"error,omitempty" yaml:"error,omitempty"`
+	Err        error  `json:"-" yaml:"-"`
+}
+
+// Outdated checks all installed packages for newer versions, fetching at most jobs specs at a time.
+// Wanted is the version update would install and Latest is the latest version of the spec.
+func (k Kindly) Outdated(ctx context.Context, jobs int) (o []OutdatedPackage, err error) {
+	// No packages are installed before the manifests directory is created
+	files, err := ioutil.ReadDir(k.cfg.ManifestDir)
+	if os.IsNotExist(err) {
+		return o, nil
+	} else if err != nil {
+		return o, err
+	}
+
+	var ls []pkgManifest
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".yaml") {
+			continue
+		}
+		l, err := readManifest(filepath.Join(k.cfg.ManifestDir, f.Name()))
+		if err != nil {
+			return o, err
+		}
+		ls = append(ls, l)
+	}
+
+	o = make([]OutdatedPackage, len(ls))
+
+	runJobs(len(ls), jobs, func(i int) {
+		l := ls[i]
+		o[i] = OutdatedPackage{Name: l.Name, Installed: l.Version, Constraint: l.Constraint, Held: l.Held}
+
+		_, yc, err := k.getValidYConfig(ctx, k.manifestRef(&l))
+		if err != nil {
+			o[i].Err = err
+			o[i].Error = err.Error()
+			return
+		}
+		o[i].Latest = yc.Spec.Version
+		o[i].Wanted = yc.Spec.Version
+
+		// Update stays within the version constraint and does not change held packages
+		if len(l.Constraint) > 0 {
+			c, err := parseConstraint(l.Constraint)
+			if err != nil {
+				o[i].Err = err
+				o[i].Error = err.Error()
+				return
+			}
+			o[i].Wanted, _ = c.latest(yc.AvailableVersions())
+		}
+		if l.Held || semver.Compare(o[i].Wanted, l.Version) < 0 {
+			o[i].Wanted = l.Version
+		}
+
+		o[i].Outdated = semver.Compare(l.Version, o[i].Latest) < 0
+	})
+
+	return o, nil
+}
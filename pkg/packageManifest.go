@@ -17,12 +17,58 @@ limitations under the License.
 // Package pkg is for implementing commands
 package pkg
 
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
 type pkgManifest struct {
 	Name       string   `yaml:"name"`
 	Source     string   `yaml:"source"`
 	Date       string   `yaml:"date"`
 	Version    string   `yaml:"version"`
+	Constraint string   `yaml:"constraint,omitempty"`
+	Held       bool     `yaml:"held,omitempty"`
+	URL        string   `yaml:"url,omitempty"`
+	Sha256     string   `yaml:"sha256,omitempty"`
 	Bin        []string `yaml:"bin"`
 	Completion []string `yaml:"completion"`
 	Man        []string `yaml:"man"`
 }
+
+// files returns the paths of the files of manifest l in the output directories
+func (k Kindly) files(l pkgManifest) (f []string) {
+	for _, n := range l.Bin {
+		f = append(f, filepath.Join(k.cfg.OutBinDir, n))
+	}
+	for _, n := range l.Completion {
+		f = append(f, filepath.Join(k.cfg.OutCompletionDir, n))
+	}
+	for _, n := range l.Man {
+		f = append(f, filepath.Join(k.cfg.OutManDir, n))
+	}
+	return f
+}
+
+// readManifest reads the package manifest file filename
+func readManifest(filename string) (l pkgManifest, err error) {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return l, err
+	}
+	err = yaml.Unmarshal(file, &l)
+	return l, err
+}
+
+// readInstalled reads the package manifest of installed package n
+func (k Kindly) readInstalled(n string) (pkgManifest, error) {
+	l, err := readManifest(filepath.Join(k.cfg.ManifestDir, n+".yaml"))
+	if os.IsNotExist(err) {
+		return l, fmt.Errorf("%w: %s", ErrNotInstalled, n)
+	}
+	return l, err
+}
@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// Pin holds installed package n at its current version, so that update skips it
+func (k Kindly) Pin(ctx context.Context, n string) error {
+	return k.setHeld(n, true)
+}
+
+// Unpin releases a held package n, so that update updates it again
+func (k Kindly) Unpin(ctx context.Context, n string) error {
+	return k.setHeld(n, false)
+}
+
+// setHeld records the held flag in the manifest of package n
+func (k Kindly) setHeld(n string, held bool) error {
+	fsMu.Lock()
+	defer fsMu.Unlock()
+
+	l, err := k.readInstalled(n)
+	if err != nil {
+		return err
+	}
+
+	k.logger.Debug("Writing file", "package", n, "path", filepath.Join(k.cfg.ManifestDir, n+".yaml"))
+
+	l.Held = held
+	return writeManifest(l, k.cfg.ManifestDir)
+}
@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"io"
+)
+
+// Progress stages of an install
+const (
+	// ProgressResolved is reported when the version and asset of the package are resolved from its spec
+	ProgressResolved = "resolved"
+	// ProgressDownloading is reported as bytes of the asset are downloaded
+	ProgressDownloading = "downloading"
+	// ProgressDownloaded is reported when the asset is downloaded and verified, or found in the download cache
+	ProgressDownloaded = "downloaded"
+	// ProgressInstalled is reported when the files of the package are installed
+	ProgressInstalled = "installed"
+)
+
+// ProgressEvent reports the progress of the install of a package.
+// Bytes and Total are the downloaded bytes and the size of the asset, or -1 if the size is unknown.
+type ProgressEvent struct {
+	Package string
+	Version string
+	Stage   string
+	URL     string
+	Bytes   int64
+	Total   int64
+}
+
+// report calls the progress function of the client with event e, if set
+func (k Kindly) report(e ProgressEvent) {
+	if k.progress != nil {
+		k.progress(e)
+	}
+}
+
+// downloadProgress returns the function that reports the download progress of the asset of dl,
+// or nil if the client has no progress function
+func (k Kindly) downloadProgress(dl dlInfo) func(n int64, total int64) {
+	if k.progress == nil {
+		return nil
+	}
+	return func(n int64, total int64) {
+		k.progress(ProgressEvent{Package: dl.Name, Version: dl.Version, Stage: ProgressDownloading, URL: dl.URL, Bytes: n, Total: total})
+	}
+}
+
+// progressReader calls progress with the number of bytes read from r, counting from n
+type progressReader struct {
+	r        io.Reader
+	n        int64
+	total    int64
+	progress func(n int64, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.n += int64(n)
+		p.progress(p.n, p.total)
+	}
+	return n, err
+}
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/borkod/kindly/config"
+	"gopkg.in/yaml.v2"
+)
+
+// ProjectFileName is the name of the file that declares the tools of a project
+const ProjectFileName = "kindly.yaml"
+
+// ProjectDirName is the name of the project-local kindly directory
+const ProjectDirName = ".kindly"
+
+// ProjectFile declares the tools a project requires as name@version or name@constraint
+type ProjectFile struct {
+	Tools []string `yaml:"tools"`
+}
+
+// FindProjectFile returns the path of the project file in directory dir or its closest parent directory
+func FindProjectFile(dir string) (string, error) {
+	for {
+		filename := filepath.Join(dir, ProjectFileName)
+		if _, err := os.Stat(filename); err == nil {
+			return filename, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("No " + ProjectFileName + " file found")
+		}
+		dir = parent
+	}
+}
+
+// ReadProjectFile reads project file filename
+func ReadProjectFile(filename string) (p ProjectFile, err error) {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return p, err
+	}
+	if err = yaml.Unmarshal(file, &p); err != nil {
+		return p, err
+	}
+	if len(p.Tools) == 0 {
+		return p, errors.New("No tools listed in: " + filename)
+	}
+	return p, nil
+}
+
+// ProjectConfig returns config c with the output, manifest and package versions directories
+// in the project-local kindly directory of project directory dir.
+// The download cache is shared with global installs.
+func ProjectConfig(c config.Config, dir string) config.Config {
+	root := filepath.Join(dir, ProjectDirName)
+
+	c.OutBinDir = filepath.Join(root, "bin")
+	c.OutCompletionDir = filepath.Join(root, "completion")
+	c.OutManDir = filepath.Join(root, "man")
+	c.ManifestDir = filepath.Join(root, "manifests")
+	c.PkgDir = filepath.Join(root, "pkgs")
+
+	return c
+}
@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// AllowedProjectsFileName is the name of the file, in the kindly directory, that lists the allowed projects
+const AllowedProjectsFileName = "allowed.yaml"
+
+// AllowedProjects lists the project directories whose project-local directories may be added to the shell environment,
+// with the SHA256 value of the project file of each project when it was allowed
+type AllowedProjects struct {
+	Projects map[string]string `yaml:"projects"`
+}
+
+// ReadAllowedProjects reads the allowed projects file filename. A missing file allows no project.
+func ReadAllowedProjects(filename string) (a AllowedProjects, err error) {
+	file, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return a, nil
+	} else if err != nil {
+		return a, err
+	}
+	err = yaml.Unmarshal(file, &a)
+	return a, err
+}
+
+// Allowed reports whether project directory dir is allowed and its project file has not changed since
+func (a AllowedProjects) Allowed(dir string) bool {
+	dir, sum, err := projectSum(dir)
+	if err != nil {
+		return false
+	}
+	s, ok := a.Projects[dir]
+	return ok && s == sum
+}
+
+// AllowProject records project directory dir, with the current SHA256 value of its project file,
+// in the allowed projects file filename
+func AllowProject(filename string, dir string) error {
+	a, err := ReadAllowedProjects(filename)
+	if err != nil {
+		return err
+	}
+
+	dir, sum, err := projectSum(dir)
+	if err != nil {
+		return err
+	}
+
+	if a.Projects == nil {
+		a.Projects = make(map[string]string)
+	}
+	a.Projects[dir] = sum
+
+	return writeAllowedProjects(filename, a)
+}
+
+// DenyProject removes project directory dir from the allowed projects file filename
+func DenyProject(filename string, dir string) error {
+	a, err := ReadAllowedProjects(filename)
+	if err != nil {
+		return err
+	}
+
+	dir, _, err = projectSum(dir)
+	if err != nil {
+		return err
+	}
+	delete(a.Projects, dir)
+
+	return writeAllowedProjects(filename, a)
+}
+
+// projectSum returns the absolute path of project directory dir and the SHA256 value of its project file,
+// or an empty value if it has no project file
+func projectSum(dir string) (string, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir, "", err
+	}
+	if d, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = d
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, ProjectFileName))
+	if os.IsNotExist(err) {
+		return dir, "", nil
+	} else if err != nil {
+		return dir, "", err
+	}
+
+	return dir, sha256Hex(b), nil
+}
+
+func writeAllowedProjects(filename string, a AllowedProjects) error {
+	b, err := yaml.Marshal(a)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, b, 0644)
+}
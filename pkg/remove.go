@@ -2,65 +2,64 @@ package pkg
 
 import (
 	"context"
-	"io/ioutil"
 	"os"
 	"path/filepath"
-
-	"gopkg.in/yaml.v2"
 )
 
 // Remove function implements remove command
 func (k Kindly) Remove(ctx context.Context, p string) (err error) {
-	filename := filepath.Join(k.cfg.ManifestDir, p+".yaml")
-	l := new(pkgManifest)
+	return k.remove(ctx, p).Err
+}
 
-	// Read package manifest
-	file, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return err
-	}
+// RemoveAll removes packages and returns the results in the order of the packages
+func (k Kindly) RemoveAll(ctx context.Context, names []string) []PackageResult {
+	r := make([]PackageResult, 0, len(names))
 
-	if err := yaml.Unmarshal(file, l); err != nil {
-		return err
+	for _, n := range names {
+		k.logger.Debug("Removing package", "package", n)
+		r = append(r, k.remove(ctx, n))
 	}
 
-	for _, n := range l.Bin {
-		if k.cfg.Verbose {
-			k.logger.Println("Deleting file: ", filepath.Join(k.cfg.OutBinDir, n))
-		}
-		if err := os.Remove(filepath.Join(k.cfg.OutBinDir, n)); err != nil {
-			k.logger.Println("ERROR")
-			k.logger.Println(err)
-		}
+	return r
+}
+
+// remove removes package p.
+// All files of the package are deleted even if some fail; the errors are returned together.
+func (k Kindly) remove(ctx context.Context, p string) PackageResult {
+	r := PackageResult{Package: p, Status: StatusRemoved}
+
+	// Read package manifest
+	l, err := k.readInstalled(p)
+	if err != nil {
+		r.Fail(err)
+		return r
 	}
+	r.Version = l.Version
+	r.Files = k.files(l)
 
-	for _, n := range l.Completion {
-		if k.cfg.Verbose {
-			k.logger.Println("Deleting file: ", filepath.Join(k.cfg.OutCompletionDir, n))
-		}
-		if err := os.Remove(filepath.Join(k.cfg.OutCompletionDir, n)); err != nil {
-			k.logger.Println("ERROR")
-			k.logger.Println(err)
+	var errs MultiError
+	del := func(path string) {
+		k.logger.Debug("Deleting file", "package", l.Name, "path", path)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
 		}
 	}
 
-	for _, n := range l.Man {
-		if k.cfg.Verbose {
-			k.logger.Println("Deleting file: ", filepath.Join(k.cfg.OutManDir, n))
-		}
-		if err := os.Remove(filepath.Join(k.cfg.OutManDir, n)); err != nil {
-			k.logger.Println("ERROR")
-			k.logger.Println(err)
-		}
+	for _, f := range r.Files {
+		del(f)
 	}
 
-	if k.cfg.Verbose {
-		k.logger.Println("Deleting file: ", filename)
+	del(filepath.Join(k.cfg.ManifestDir, l.Name+".yaml"))
+
+	// Delete all installed versions of the package
+	k.logger.Debug("Deleting directory", "package", l.Name, "path", filepath.Join(k.cfg.PkgDir, l.Name))
+	if err := os.RemoveAll(filepath.Join(k.cfg.PkgDir, l.Name)); err != nil {
+		errs = append(errs, err)
 	}
-	if err := os.Remove(filename); err != nil {
-		k.logger.Println("ERROR")
-		k.logger.Println(err)
+
+	if len(errs) > 0 {
+		r.Fail(errs)
 	}
 
-	return nil
+	return r
 }
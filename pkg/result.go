@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"sync"
+)
+
+// Package result status values
+const (
+	StatusInstalled = "installed"
+	StatusUpdated   = "updated"
+	StatusUpToDate  = "up to date"
+	StatusHeld      = "held"
+	StatusRemoved   = "removed"
+	StatusPinned    = "pinned"
+	StatusUnpinned  = "unpinned"
+	StatusActive    = "active"
+	StatusAvailable = "available"
+	StatusFailed    = "failed"
+)
+
+// PackageResult is the outcome of a command for one package.
+// Files are the paths of the files the command installed or removed.
+type PackageResult struct {
+	Package string   `json:"package" yaml:"package"`
+	Version string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Status  string   `json:"status" yaml:"status"`
+	Files   []string `json:"files,omitempty" yaml:"files,omitempty"`
+	Error   string   `json:"error,omitempty" yaml:"error,omitempty"`
+	Err     error    `json:"-" yaml:"-"`
+}
+
+// Fail records error err as the outcome of the command
+func (r *PackageResult) Fail(err error) {
+	r.Status = StatusFailed
+	r.Err = err
+	r.Error = err.Error()
+}
+
+// runJobs calls job for each index from 0 to n-1, running at most jobs calls at a time
+func runJobs(n int, jobs int, job func(i int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			job(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,204 @@
+package pkg
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/borkod/kindly/config"
+	"gopkg.in/yaml.v2"
+)
+
+// indexFileName is the name of the index file published in the root of a spec source
+const indexFileName = "index.yaml"
+
+// sigFileSuffix is appended to the index file name to get its signature file name
+const sigFileSuffix = ".sig"
+
+// SpecIndex lists the package specs published by a spec source
+type SpecIndex struct {
+	Specs []IndexEntry `yaml:"specs"`
+}
+
+// IndexEntry describes a package spec file listed in a SpecIndex
+type IndexEntry struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	Sha256  string `yaml:"sha256,omitempty"`
+}
+
+// parseIndex parses the contents of an index file
+func parseIndex(b []byte) (idx SpecIndex, err error) {
+	err = yaml.Unmarshal(b, &idx)
+	return idx, err
+}
+
+// find returns the index entry for package n
+func (idx SpecIndex) find(n string) (IndexEntry, bool) {
+	for _, e := range idx.Specs {
+		if e.Name == n {
+			return e, true
+		}
+	}
+	return IndexEntry{}, false
+}
+
+// Contains reports whether the index lists entry e with the same SHA256 value
+func (idx SpecIndex) Contains(e IndexEntry) bool {
+	f, ok := idx.find(e.Name)
+	return ok && strings.EqualFold(f.Sha256, e.Sha256)
+}
+
+// ParsePublicKey decodes a base64 encoded ed25519 public key
+func ParsePublicKey(publicKey string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("Invalid public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// VerifyIndex checks the ed25519 signature of an index file against a base64 encoded public key
+// and returns the parsed index. The signature is the base64 encoded contents of the signature file.
+func VerifyIndex(index []byte, sig []byte, publicKey string) (SpecIndex, error) {
+	var idx SpecIndex
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return idx, err
+	}
+
+	s, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || len(s) != ed25519.SignatureSize {
+		return idx, fmt.Errorf("%w: Invalid index signature", ErrVerification)
+	}
+
+	if !ed25519.Verify(key, index, s) {
+		return idx, fmt.Errorf("%w: Index signature does not match the public key", ErrVerification)
+	}
+
+	return parseIndex(index)
+}
+
+// SignIndex returns the base64 encoded ed25519 signature of an index file
+// for a base64 encoded private key
+func SignIndex(index []byte, privateKey string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(privateKey))
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("Invalid private key")
+	}
+
+	s := ed25519.Sign(ed25519.PrivateKey(key), index)
+	return []byte(base64.StdEncoding.EncodeToString(s) + "\n"), nil
+}
+
+// BuildIndex returns the index of the spec files in local directory dir
+func BuildIndex(ctx context.Context, dir string) (idx SpecIndex, err error) {
+	src := fileSource{dir: expandPath(dir)}
+
+	names, err := src.List(ctx)
+	if err != nil {
+		return idx, err
+	}
+
+	for _, n := range names {
+		b, err := src.Get(ctx, n)
+		if err != nil {
+			return idx, err
+		}
+
+		yc, err := parseSpec(n, b)
+		if err != nil {
+			return idx, err
+		}
+
+		idx.Specs = append(idx.Specs, IndexEntry{Name: n, Version: yc.Spec.Version, Sha256: sha256Hex(b)})
+	}
+
+	return idx, nil
+}
+
+// indexCache keeps the verified indexes of the spec sources of a client, so that the index of a source
+// is fetched and verified once rather than for every spec read from it
+type indexCache struct {
+	sync.Mutex
+	m map[config.Source]SpecIndex
+}
+
+// cachedIndex returns the signature verified index of source c, verifying it on first use
+func (k Kindly) cachedIndex(ctx context.Context, c config.Source, src SpecSource) (SpecIndex, error) {
+	k.indexes.Lock()
+	defer k.indexes.Unlock()
+
+	if idx, ok := k.indexes.m[c]; ok {
+		return idx, nil
+	}
+
+	idx, err := verifiedIndex(ctx, c, src)
+	if err != nil {
+		return idx, err
+	}
+
+	if k.indexes.m == nil {
+		k.indexes.m = make(map[config.Source]SpecIndex)
+	}
+	k.indexes.m[c] = idx
+
+	return idx, nil
+}
+
+// verifiedIndex returns the signature verified index of source c.
+// If c.Index is set the index is read from that local file instead of the source.
+func verifiedIndex(ctx context.Context, c config.Source, src SpecSource) (SpecIndex, error) {
+	var index, sig []byte
+	var err error
+
+	if len(c.Index) > 0 {
+		if index, err = ioutil.ReadFile(expandPath(c.Index)); err != nil {
+			return SpecIndex{}, err
+		}
+		if sig, err = ioutil.ReadFile(expandPath(c.Index) + sigFileSuffix); err != nil {
+			return SpecIndex{}, err
+		}
+	} else {
+		if index, err = src.Fetch(ctx, indexFileName); err != nil {
+			return SpecIndex{}, err
+		}
+		if sig, err = src.Fetch(ctx, indexFileName+sigFileSuffix); err != nil {
+			return SpecIndex{}, err
+		}
+	}
+
+	idx, err := VerifyIndex(index, sig, c.PublicKey)
+	if err != nil {
+		return idx, fmt.Errorf("%w: %s", err, c.Name)
+	}
+
+	return idx, nil
+}
+
+// verifySpec checks that spec b of package n matches the sha256 value in the verified index
+func verifySpec(idx SpecIndex, n string, b []byte) error {
+	if _, ok := idx.find(n); !ok {
+		return fmt.Errorf("%w: Package not listed in signed index: %s", ErrVerification, n)
+	}
+
+	if !idx.Contains(IndexEntry{Name: n, Sha256: sha256Hex(b)}) {
+		return fmt.Errorf("%w: Spec SHA256 does not match signed index: %s", ErrVerification, n)
+	}
+
+	return nil
+}
+
+// sha256Hex returns the hex encoded sha256 value of b
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,252 @@
+package pkg
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/borkod/kindly/config"
+	"gopkg.in/yaml.v2"
+)
+
+const testSpec = `spec:
+  name: foo
+  version: v1.0.0
+  assets:
+    linux_amd64:
+      url: https://example.com/foo_{{.Version}}.tar.gz
+  bin: [foo]
+`
+
+// testKeys returns a new base64 encoded ed25519 public and private key
+func testKeys(t *testing.T) (string, string) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv)
+}
+
+// writeSignedSource writes spec foo and its signed index into a new directory and returns the directory
+func writeSignedSource(t *testing.T, privateKey string) string {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "foo.yaml"), testSpec)
+
+	idx, err := BuildIndex(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := yaml.Marshal(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := SignIndex(index, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, indexFileName), string(index))
+	writeFile(t, filepath.Join(dir, indexFileName+sigFileSuffix), string(sig))
+
+	return dir
+}
+
+func TestVerifyIndex(t *testing.T) {
+	pub, priv := testKeys(t)
+	otherPub, _ := testKeys(t)
+
+	index := []byte("specs:\n- name: foo\n  version: v1.0.0\n  sha256: " + sha256Hex([]byte(testSpec)) + "\n")
+	sig, err := SignIndex(index, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tamperedSig := []byte(base64.StdEncoding.EncodeToString(make([]byte, ed25519.SignatureSize)))
+
+	tests := []struct {
+		name    string
+		index   []byte
+		sig     []byte
+		key     string
+		wantErr string
+	}{
+		{"valid", index, sig, pub, ""},
+		{"tampered index", []byte(strings.Replace(string(index), "v1.0.0", "v1.0.1", 1)), sig, pub, "Verification failed: Index signature does not match the public key"},
+		{"tampered signature", index, tamperedSig, pub, "Verification failed: Index signature does not match the public key"},
+		{"truncated signature", index, sig[:10], pub, "Verification failed: Invalid index signature"},
+		{"other key", index, sig, otherPub, "Verification failed: Index signature does not match the public key"},
+		{"invalid key", index, sig, "not a key", "Invalid public key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, err := VerifyIndex(tt.index, tt.sig, tt.key)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("VerifyIndex() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIndex() error = %v", err)
+			}
+			if !idx.Contains(IndexEntry{Name: "foo", Sha256: sha256Hex([]byte(testSpec))}) {
+				t.Errorf("VerifyIndex() = %+v, want entry for foo", idx)
+			}
+		})
+	}
+}
+
+func TestFindSpecSignedIndex(t *testing.T) {
+	pub, priv := testKeys(t)
+	otherPub, _ := testKeys(t)
+
+	tests := []struct {
+		name    string
+		key     string
+		tamper  func(t *testing.T, dir string)
+		wantErr string
+	}{
+		{"valid", pub, nil, ""},
+		{"tampered spec", pub, func(t *testing.T, dir string) {
+			writeFile(t, filepath.Join(dir, "foo.yaml"), strings.Replace(testSpec, "example.com", "evil.example.com", 1))
+		}, "Verification failed: Spec SHA256 does not match signed index: foo"},
+		{"tampered index", pub, func(t *testing.T, dir string) {
+			writeFile(t, filepath.Join(dir, indexFileName), "specs:\n- name: foo\n  sha256: 00\n")
+		}, "Verification failed: Index signature does not match the public key: kindly"},
+		{"tampered signature", pub, func(t *testing.T, dir string) {
+			writeFile(t, filepath.Join(dir, indexFileName+sigFileSuffix), base64.StdEncoding.EncodeToString(make([]byte, ed25519.SignatureSize)))
+		}, "Verification failed: Index signature does not match the public key: kindly"},
+		{"other key", otherPub, nil, "Verification failed: Index signature does not match the public key: kindly"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSignedSource(t, priv)
+			if tt.tamper != nil {
+				tt.tamper(t, dir)
+			}
+
+			cfg := DefaultConfig()
+			cfg.Source = "file://" + dir
+			cfg.PublicKey = tt.key
+			k := New(WithConfig(cfg))
+
+			_, b, err := k.findSpec(context.Background(), "foo")
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("findSpec() error = %v, want %q", err, tt.wantErr)
+				}
+				if !errors.Is(err, ErrVerification) {
+					t.Errorf("findSpec() error = %v, want %v", err, ErrVerification)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findSpec() error = %v", err)
+			}
+			if string(b) != testSpec {
+				t.Errorf("findSpec() = %q, want %q", b, testSpec)
+			}
+		})
+	}
+}
+
+func TestFindSpecUnlistedSpec(t *testing.T) {
+	pub, priv := testKeys(t)
+	dir := writeSignedSource(t, priv)
+	writeFile(t, filepath.Join(dir, "bar.yaml"), strings.Replace(testSpec, "name: foo", "name: bar", 1))
+
+	cfg := DefaultConfig()
+	cfg.Source = "file://" + dir
+	cfg.PublicKey = pub
+	k := New(WithConfig(cfg))
+
+	want := "Verification failed: Package not listed in signed index: bar"
+	if _, _, err := k.findSpec(context.Background(), "bar"); err == nil || err.Error() != want {
+		t.Fatalf("findSpec() error = %v, want %q", err, want)
+	}
+}
+
+func TestFindSpecVerifiesIndexOnce(t *testing.T) {
+	pub, priv := testKeys(t)
+	dir := writeSignedSource(t, priv)
+
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		http.FileServer(http.Dir(dir)).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	cfg.Source = srv.URL
+	cfg.PublicKey = pub
+	k := New(WithConfig(cfg), WithHTTPClient(srv.Client()))
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := k.findSpec(context.Background(), "foo"); err != nil {
+			t.Fatalf("findSpec() error = %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if n := requests["/"+indexFileName]; n != 1 {
+		t.Errorf("index requests = %d, want 1", n)
+	}
+	if n := requests["/foo.yaml"]; n != 3 {
+		t.Errorf("spec requests = %d, want 3", n)
+	}
+}
+
+func TestSources(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want []config.Source
+	}{
+		{
+			"default source keeps public key and index",
+			config.Config{Source: "file:///specs", PublicKey: "key", Index: "index.yaml"},
+			[]config.Source{{Name: DefaultSourceName, URL: "file:///specs", PublicKey: "key", Index: "index.yaml"}},
+		},
+		{
+			"configured sources",
+			config.Config{Source: "file:///specs", PublicKey: "key", Sources: []config.Source{{Name: "a", URL: "file:///a"}}},
+			[]config.Source{{Name: "a", URL: "file:///a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sources(tt.cfg)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Sources() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Sources()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// writeFile writes s to file filename
+func writeFile(t *testing.T, filename string, s string) {
+	if err := ioutil.WriteFile(filename, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,300 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/borkod/kindly/config"
+	"github.com/google/go-github/github"
+)
+
+// DefaultSourceName is the name of the source set by the Source setting
+const DefaultSourceName = "kindly"
+
+// githubRawPrefix is the URL prefix of raw files in GitHub repos
+const githubRawPrefix = "https://raw.githubusercontent.com/"
+
+// errNotFound is returned when a requested spec or file does not exist in a source
+var errNotFound = errors.New("not found")
+
+// SpecSource is a repository of kindly package spec files
+type SpecSource interface {
+	// Get returns the raw YAML spec for the named package
+	Get(ctx context.Context, name string) ([]byte, error)
+	// Fetch returns the contents of a file in the root of the source
+	Fetch(ctx context.Context, file string) ([]byte, error)
+	// List returns the names of all packages available in the source
+	List(ctx context.Context) ([]string, error)
+	// Describe returns a human readable description of the source
+	Describe() string
+}
+
+// NewSpecSource returns the SpecSource for src, chosen by its scheme.
+// Network sources use client, or http.DefaultClient if client is nil.
+//
+// Supported schemes:
+//
+//	file:///path/to/specs            local directory of spec files
+//	https://example.com/specs/       HTTP directory with an index.yaml file
+//	github://owner/repo/path[@ref]   directory in a GitHub repo (default ref is main)
+//
+// https://raw.githubusercontent.com/owner/repo/ref/path URLs, such as the Source default of
+// older versions of kindly, are used as the github://owner/repo/path@ref directory.
+func NewSpecSource(src string, client *http.Client) (SpecSource, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	switch {
+	case strings.HasPrefix(src, "file://"):
+		return fileSource{dir: expandPath(strings.TrimPrefix(src, "file://"))}, nil
+	case strings.HasPrefix(src, githubRawPrefix):
+		return newGithubRawSource(strings.TrimPrefix(src, githubRawPrefix), client)
+	case strings.HasPrefix(src, "https://"), strings.HasPrefix(src, "http://"):
+		if !isValidUrl(src) {
+			return nil, errors.New("Invalid source URL: " + src)
+		}
+		return httpSource{base: strings.TrimSuffix(src, "/") + "/", client: client}, nil
+	case strings.HasPrefix(src, "github://"):
+		return newGithubSource(strings.TrimPrefix(src, "github://"), client)
+	}
+	return nil, errors.New("Unsupported source: " + src)
+}
+
+// Sources returns the spec sources of config c in priority order.
+// If no sources are configured, the Source, PublicKey and Index settings are used as the only source.
+func Sources(c config.Config) []config.Source {
+	if len(c.Sources) > 0 {
+		return c.Sources
+	}
+	return []config.Source{{Name: DefaultSourceName, URL: c.Source, PublicKey: c.PublicKey, Index: c.Index}}
+}
+
+// sources returns the configured spec sources in priority order
+func (k Kindly) sources() []config.Source {
+	return Sources(k.cfg)
+}
+
+// findSpec returns the raw spec of package n and the source it was found in.
+// Sources are searched in priority order unless n is pinned to a source as source/name.
+func (k Kindly) findSpec(ctx context.Context, n string) (config.Source, []byte, error) {
+	srcs := k.sources()
+
+	if i := strings.Index(n, "/"); i >= 0 {
+		pinned := n[:i]
+		n = n[i+1:]
+		srcs = nil
+		for _, c := range k.sources() {
+			if c.Name == pinned {
+				srcs = append(srcs, c)
+			}
+		}
+		if len(srcs) == 0 {
+			return config.Source{}, nil, errors.New("Unknown source: " + pinned)
+		}
+	}
+
+	for _, c := range srcs {
+		src, err := NewSpecSource(c.URL, k.httpClient())
+		if err != nil {
+			return c, nil, err
+		}
+
+		b, err := src.Get(ctx, n)
+		if errors.Is(err, errNotFound) {
+			continue
+		} else if err != nil {
+			return c, nil, err
+		}
+
+		// Verify the spec against the signed index before using it
+		if len(c.PublicKey) > 0 {
+			idx, err := k.cachedIndex(ctx, c, src)
+			if err != nil {
+				return c, nil, err
+			}
+			if err := verifySpec(idx, n, b); err != nil {
+				return c, nil, err
+			}
+		}
+
+		return c, b, nil
+	}
+
+	return config.Source{}, nil, fmt.Errorf("%w: %s", ErrPackageNotFound, n)
+}
+
+// specFileName returns the spec file name for package n
+func specFileName(n string) string {
+	return n + ".yaml"
+}
+
+// isSpecFile reports whether a file name in a source directory is a package spec
+func isSpecFile(n string) bool {
+	return strings.HasSuffix(n, ".yaml") && n != indexFileName
+}
+
+// fileSource reads spec files from a local directory
+type fileSource struct {
+	dir string
+}
+
+func (s fileSource) Get(ctx context.Context, name string) ([]byte, error) {
+	return s.Fetch(ctx, specFileName(name))
+}
+
+func (s fileSource) Fetch(ctx context.Context, file string) ([]byte, error) {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, file))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", errNotFound, filepath.Join(s.dir, file))
+	}
+	return b, err
+}
+
+func (s fileSource) List(ctx context.Context) (n []string, err error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return n, err
+	}
+
+	for _, f := range files {
+		if !f.IsDir() && isSpecFile(f.Name()) {
+			n = append(n, strings.TrimSuffix(f.Name(), ".yaml"))
+		}
+	}
+
+	return n, nil
+}
+
+func (s fileSource) Describe() string {
+	return "local directory " + s.dir
+}
+
+// httpSource reads spec files from a web server.
+// Packages are listed from the index.yaml file in the base URL.
+type httpSource struct {
+	base   string
+	client *http.Client
+}
+
+func (s httpSource) Get(ctx context.Context, name string) ([]byte, error) {
+	return s.Fetch(ctx, specFileName(name))
+}
+
+func (s httpSource) Fetch(ctx context.Context, file string) ([]byte, error) {
+	return fetchURL(ctx, s.client, s.base+file)
+}
+
+func (s httpSource) List(ctx context.Context) (n []string, err error) {
+	b, err := s.Fetch(ctx, indexFileName)
+	if err != nil {
+		return n, err
+	}
+
+	idx, err := parseIndex(b)
+	if err != nil {
+		return n, err
+	}
+
+	for _, e := range idx.Specs {
+		n = append(n, e.Name)
+	}
+
+	return n, nil
+}
+
+func (s httpSource) Describe() string {
+	return "HTTP index " + s.base + indexFileName
+}
+
+// githubSource reads spec files from a directory in a GitHub repo
+type githubSource struct {
+	owner  string
+	repo   string
+	path   string
+	ref    string
+	client *http.Client
+}
+
+// newGithubSource parses a owner/repo/path[@ref] source location
+func newGithubSource(loc string, client *http.Client) (githubSource, error) {
+	s := githubSource{client: client}
+
+	s.ref = "main"
+	if i := strings.LastIndex(loc, "@"); i >= 0 {
+		s.ref = loc[i+1:]
+		loc = loc[:i]
+	}
+
+	sInfo := strings.SplitN(strings.Trim(loc, "/"), "/", 3)
+	if len(sInfo) < 2 || len(sInfo[0]) == 0 || len(sInfo[1]) == 0 || len(s.ref) == 0 {
+		return s, errors.New("Invalid GitHub source: github://" + loc)
+	}
+
+	s.owner = sInfo[0]
+	s.repo = sInfo[1]
+	if len(sInfo) > 2 {
+		s.path = strings.Trim(sInfo[2], "/")
+	}
+
+	return s, nil
+}
+
+// newGithubRawSource returns the GitHub source of raw.githubusercontent.com location owner/repo/ref/path
+func newGithubRawSource(loc string, client *http.Client) (githubSource, error) {
+	sInfo := strings.SplitN(strings.Trim(loc, "/"), "/", 4)
+	if len(sInfo) < 3 || len(sInfo[0]) == 0 || len(sInfo[1]) == 0 || len(sInfo[2]) == 0 {
+		return githubSource{}, errors.New("Invalid GitHub source: " + githubRawPrefix + loc)
+	}
+
+	s := githubSource{client: client, owner: sInfo[0], repo: sInfo[1], ref: sInfo[2]}
+	if len(sInfo) > 3 {
+		s.path = strings.Trim(sInfo[3], "/")
+	}
+
+	return s, nil
+}
+
+// rawURL returns the raw.githubusercontent.com URL of file f in the source directory
+func (s githubSource) rawURL(f string) string {
+	p := f
+	if len(s.path) > 0 {
+		p = s.path + "/" + f
+	}
+	return githubRawPrefix + s.owner + "/" + s.repo + "/" + s.ref + "/" + p
+}
+
+func (s githubSource) Get(ctx context.Context, name string) ([]byte, error) {
+	return s.Fetch(ctx, specFileName(name))
+}
+
+func (s githubSource) Fetch(ctx context.Context, file string) ([]byte, error) {
+	return fetchURL(ctx, s.client, s.rawURL(file))
+}
+
+func (s githubSource) List(ctx context.Context) (n []string, err error) {
+	client := github.NewClient(s.client)
+
+	_, dir, _, err := client.Repositories.GetContents(ctx, s.owner, s.repo, s.path, &github.RepositoryContentGetOptions{Ref: s.ref})
+	if err != nil {
+		return n, err
+	}
+
+	for _, f := range dir {
+		if f.GetType() == "file" && isSpecFile(f.GetName()) {
+			n = append(n, strings.TrimSuffix(f.GetName(), ".yaml"))
+		}
+	}
+
+	return n, nil
+}
+
+func (s githubSource) Describe() string {
+	return "GitHub repo " + s.owner + "/" + s.repo + "/" + s.path + "@" + s.ref
+}
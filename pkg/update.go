@@ -2,38 +2,95 @@ package pkg
 
 import (
 	"context"
-	"io/ioutil"
-	"path/filepath"
+	"os"
 
 	"golang.org/x/mod/semver"
-	"gopkg.in/yaml.v2"
 )
 
-// Update function implements update command
-func (k Kindly) Update(ctx context.Context, n string) (err error) {
-	filename := filepath.Join(k.cfg.ManifestDir, n+".yaml")
-	l := new(pkgManifest)
+// Update function implements update command.
+// The package is updated within the version constraint it was installed with, unless latest is set.
+func (k Kindly) Update(ctx context.Context, n string, latest bool) (err error) {
+	return k.update(ctx, n, latest).Err
+}
+
+// UpdateAll updates packages concurrently, running at most jobs updates at a time.
+// Results are returned in the order of the packages.
+func (k Kindly) UpdateAll(ctx context.Context, names []string, latest bool, jobs int) []PackageResult {
+	r := make([]PackageResult, len(names))
+
+	runJobs(len(names), jobs, func(i int) {
+		r[i] = k.update(ctx, names[i], latest)
+	})
+
+	return r
+}
+
+// update updates package n if a newer version is available
+func (k Kindly) update(ctx context.Context, n string, latest bool) PackageResult {
+	r := PackageResult{Package: n}
 
 	// Read package manifest
-	file, err := ioutil.ReadFile(filename)
+	l, err := k.readInstalled(n)
 	if err != nil {
-		return err
+		r.Fail(err)
+		return r
 	}
 
-	if err := yaml.Unmarshal(file, l); err != nil {
-		return err
+	// Held packages are not updated until they are unpinned
+	if l.Held {
+		r.Version = l.Version
+		r.Status = StatusHeld
+		return r
 	}
 
-	_, yc, err := k.getValidYConfig(ctx, n, false, false)
+	// Re-fetch the spec from the same place the package was installed from
+	req := k.manifestRef(&l)
+
+	// Stay within the version constraint the package was installed with
+	if len(l.Constraint) > 0 && !latest {
+		req.Ref = req.Ref + "@" + l.Constraint
+	}
+
+	dl, _, err := k.getValidYConfig(ctx, req)
 	if err != nil {
-		return err
+		r.Fail(err)
+		return r
 	}
 
-	if semver.Compare(l.Version, yc.Spec.Version) < 0 {
-		if err := k.Install(ctx, n, false, false); err != nil {
-			return err
+	r.Version = l.Version
+	r.Status = StatusUpToDate
+
+	if semver.Compare(l.Version, dl.Version) < 0 {
+		nl, err := k.install(ctx, req, nil)
+		if err != nil {
+			r.Fail(err)
+			return r
 		}
+		r.Version = nl.Version
+		r.Status = StatusUpdated
+		r.Files = k.files(nl)
+	}
+
+	return r
+}
+
+// manifestRef returns the install request of the package recorded in manifest l
+// from the source it was installed from
+func (k Kindly) manifestRef(l *pkgManifest) InstallRequest {
+	for _, c := range k.sources() {
+		if c.Name == l.Source {
+			return InstallRequest{Ref: c.Name + "/" + l.Name}
+		}
+	}
+
+	if isValidUrl(l.Source) {
+		return InstallRequest{Ref: l.Source, Source: SourceURL}
+	}
+
+	if _, err := os.Stat(expandPath(l.Source)); err == nil {
+		return InstallRequest{Ref: l.Source, Source: SourceFile}
 	}
 
-	return nil
+	// Source is no longer configured; search all sources
+	return InstallRequest{Ref: l.Name}
 }
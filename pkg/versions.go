@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Sub directories of an installed package version
+const (
+	binDir        = "bin"
+	completionDir = "completion"
+	manDir        = "man"
+)
+
+// PackageVersion describes a version of a package installed in the package versions directory
+type PackageVersion struct {
+	Version string `json:"version" yaml:"version"`
+	Date    string `json:"date" yaml:"date"`
+	Active  bool   `json:"active" yaml:"active"`
+}
+
+// Versions returns the installed versions of package n, from the oldest to the newest
+func (k Kindly) Versions(ctx context.Context, n string) (v []PackageVersion, err error) {
+	files, err := ioutil.ReadDir(filepath.Join(k.cfg.PkgDir, n))
+	if os.IsNotExist(err) {
+		return v, fmt.Errorf("%w: %s", ErrNotInstalled, n)
+	} else if err != nil {
+		return v, err
+	}
+
+	active, _ := readManifest(filepath.Join(k.cfg.ManifestDir, n+".yaml"))
+
+	for _, f := range files {
+		if !f.IsDir() || !semver.IsValid(f.Name()) {
+			continue
+		}
+		l, err := readManifest(filepath.Join(k.cfg.PkgDir, n, f.Name(), n+".yaml"))
+		if err != nil {
+			continue
+		}
+		v = append(v, PackageVersion{Version: l.Version, Date: l.Date, Active: l.Version == active.Version})
+	}
+
+	sort.Slice(v, func(i, j int) bool {
+		return semver.Compare(v[i].Version, v[j].Version) < 0
+	})
+
+	return v, nil
+}
+
+// Use makes installed version p (name@version) of a package the active version
+func (k Kindly) Use(ctx context.Context, p string) error {
+	nVer := strings.SplitN(p, "@", 2)
+	if len(nVer) < 2 {
+		return errors.New("Must provide a package version: " + p)
+	}
+
+	n := nVer[0]
+	ver := canonicalVersion(nVer[1])
+	if !semver.IsValid(ver) {
+		return errors.New("Invalid package version: " + p)
+	}
+
+	verDir := filepath.Join(k.cfg.PkgDir, n, ver)
+	l, err := readManifest(filepath.Join(verDir, n+".yaml"))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrNotInstalled, p)
+	} else if err != nil {
+		return err
+	}
+
+	var t installTxn
+	if err = k.stageLinks(&t, l, verDir); err != nil {
+		t.rollback()
+		return err
+	}
+
+	return k.activate(&t, &l)
+}
+
+// stageLinks stages links in the output directories to the files of the package version
+// in directory verDir, described by manifest l
+func (k Kindly) stageLinks(t *installTxn, l pkgManifest, verDir string) error {
+	// verDir may be a staged directory; links point to the final version directory
+	target := filepath.Join(k.cfg.PkgDir, l.Name, l.Version)
+
+	links := []struct {
+		outDir string
+		subDir string
+		files  []string
+	}{
+		{k.cfg.OutBinDir, binDir, l.Bin},
+		{k.cfg.OutCompletionDir, completionDir, l.Completion},
+		{k.cfg.OutManDir, manDir, l.Man},
+	}
+
+	for _, d := range links {
+		for _, n := range d.files {
+			k.logger.Debug("Linking file", "package", l.Name, "path", filepath.Join(d.outDir, n))
+			// A man page name includes its man<section> directory
+			if err := t.stageLink(filepath.Join(d.outDir, filepath.Dir(n)), filepath.Base(n), filepath.Join(target, d.subDir, n), filepath.Join(verDir, d.subDir, n)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// activate commits staged transaction t of the package version described by manifest l,
+// and deletes the files of the previously active version that are not part of it
+func (k Kindly) activate(t *installTxn, l *pkgManifest) error {
+	// Only one install at a time writes to the output and manifest directories
+	fsMu.Lock()
+	defer fsMu.Unlock()
+
+	old, oldErr := readManifest(filepath.Join(k.cfg.ManifestDir, l.Name+".yaml"))
+
+	// A held package stays held until it is unpinned
+	l.Held = old.Held
+
+	if err := t.commit(*l, k.cfg.ManifestDir); err != nil {
+		return err
+	}
+
+	if oldErr == nil {
+		k.removeStale(old, *l)
+	}
+
+	return nil
+}